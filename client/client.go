@@ -0,0 +1,163 @@
+// Package client is Go client of shieldoo CLI API (/cliapi).
+//
+// Example:
+//
+//	c := client.New(os.Getenv("SHIELDOO_URI"), os.Getenv("SHIELDOO_APIKEY"))
+//	servers, err := c.ListServers(context.Background())
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Client calls shieldoo CLI API, every request is authenticated by JWT token signed by API key
+type Client struct {
+	uri        string
+	apiKey     string
+	httpClient *http.Client
+	retry      RetryPolicy
+}
+
+// New creates client for shieldoo URI and API key which you can find in shieldoo admin portal
+func New(uri string, apiKey string, opts ...Option) *Client {
+	c := &Client{
+		uri:        strings.TrimSuffix(uri, "/"),
+		apiKey:     apiKey,
+		httpClient: &http.Client{Timeout: DefaultTimeout},
+		retry:      DefaultRetryPolicy,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// APIError is returned when API responds with other status than 200 OK
+type APIError struct {
+	StatusCode int
+	Status     string
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	if e.Body == "" {
+		return e.Status
+	}
+	return e.Status + " - " + e.Body
+}
+
+// IsNotFound checks if error is API error with status 404 Not Found
+func IsNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+func extractDomainFromUri(uri string) string {
+	// extract domain from uri
+	parsedURL, err := url.Parse(uri)
+	ret := ""
+	if err == nil {
+		ret = parsedURL.Hostname()
+	}
+	return ret
+}
+
+// do calls API entity (servers, firewalls or groups) with optional id and name filter,
+// request body is JSON of in (if it is not nil) and response is decoded into out (if it is not nil),
+// failed requests are retried according to retry policy
+func (c *Client) do(ctx context.Context, method string, entity string, id string, name string, in interface{}, out interface{}) error {
+	myurl := c.uri + "/cliapi/" + entity
+	if id != "" {
+		myurl += "/" + url.QueryEscape(id)
+	}
+	if name != "" {
+		// url encode name
+		myurl += "?name=" + url.QueryEscape(name)
+	}
+	var data []byte
+	// convert data to json if it is not nil
+	if in != nil {
+		var err error
+		data, err = json.Marshal(in)
+		if err != nil {
+			return err
+		}
+	}
+	for attempt := 0; ; attempt++ {
+		resp, body, err := c.send(ctx, method, myurl, data)
+		if attempt < c.retry.MaxRetries && shouldRetry(method, resp, err) {
+			if d, ok := c.retry.backoff(attempt, resp); ok {
+				if err := sleep(ctx, d); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode != http.StatusOK {
+			return &APIError{StatusCode: resp.StatusCode, Status: resp.Status, Body: strings.TrimSpace(string(body))}
+		}
+		if out == nil {
+			return nil
+		}
+		if err := json.Unmarshal(body, out); err != nil {
+			return fmt.Errorf("invalid response of %s %s: %s", method, entity, err)
+		}
+		return nil
+	}
+}
+
+// send makes one HTTP request and reads whole response
+func (c *Client) send(ctx context.Context, method string, url string, data []byte) (*http.Response, []byte, error) {
+	// create Jwt token
+	token, err := GenerateJWTAccessToken(extractDomainFromUri(c.uri), c.apiKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(data))
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("AuthToken", token)
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	return resp, body, nil
+}
+
+// list calls GET on entity and decodes response into list out,
+// API returns single object or array of objects (or null)
+func (c *Client) list(ctx context.Context, entity string, id string, name string, out interface{}) error {
+	var raw json.RawMessage
+	if err := c.do(ctx, http.MethodGet, entity, id, name, nil, &raw); err != nil {
+		return err
+	}
+	data := bytes.TrimSpace(raw)
+	if len(data) == 0 || string(data) == "null" {
+		return nil
+	}
+	if data[0] != '[' {
+		data = append(append([]byte("["), data...), ']')
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("invalid response of GET %s: %s", entity, err)
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+// ListFirewalls returns all firewalls
+func (c *Client) ListFirewalls(ctx context.Context) ([]Firewall, error) {
+	ret := []Firewall{}
+	err := c.list(ctx, "firewalls", "", "", &ret)
+	return ret, err
+}
+
+// GetFirewall returns firewall by ID, APIError with status 404 is returned if firewall does not exist
+func (c *Client) GetFirewall(ctx context.Context, id string) (*Firewall, error) {
+	var ret []Firewall
+	if err := c.list(ctx, "firewalls", id, "", &ret); err != nil {
+		return nil, err
+	}
+	if len(ret) == 0 {
+		return nil, &APIError{StatusCode: http.StatusNotFound, Status: "404 Not Found"}
+	}
+	return &ret[0], nil
+}
+
+// GetFirewallByName returns firewall by name, nil is returned if firewall does not exist
+func (c *Client) GetFirewallByName(ctx context.Context, name string) (*Firewall, error) {
+	var ret []Firewall
+	if err := c.list(ctx, "firewalls", "", name, &ret); err != nil {
+		return nil, err
+	}
+	if len(ret) == 0 {
+		return nil, nil
+	}
+	return &ret[0], nil
+}
+
+// CreateFirewall creates firewall and returns stored firewall
+func (c *Client) CreateFirewall(ctx context.Context, firewall Firewall) (*Firewall, error) {
+	firewall.Id = ""
+	var ret Firewall
+	if err := c.do(ctx, http.MethodPost, "firewalls", "", "", &firewall, &ret); err != nil {
+		return nil, err
+	}
+	return &ret, nil
+}
+
+// UpdateFirewall updates firewall identified by firewall.Id and returns stored firewall
+func (c *Client) UpdateFirewall(ctx context.Context, firewall Firewall) (*Firewall, error) {
+	var ret Firewall
+	if err := c.do(ctx, http.MethodPut, "firewalls", firewall.Id, "", &firewall, &ret); err != nil {
+		return nil, err
+	}
+	return &ret, nil
+}
+
+// DeleteFirewall deletes firewall by ID
+func (c *Client) DeleteFirewall(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, "firewalls", id, "", nil, nil)
+}
@@ -0,0 +1,61 @@
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+// ListGroups returns all groups
+func (c *Client) ListGroups(ctx context.Context) ([]Group, error) {
+	ret := []Group{}
+	err := c.list(ctx, "groups", "", "", &ret)
+	return ret, err
+}
+
+// GetGroup returns group by ID, APIError with status 404 is returned if group does not exist
+func (c *Client) GetGroup(ctx context.Context, id string) (*Group, error) {
+	var ret []Group
+	if err := c.list(ctx, "groups", id, "", &ret); err != nil {
+		return nil, err
+	}
+	if len(ret) == 0 {
+		return nil, &APIError{StatusCode: http.StatusNotFound, Status: "404 Not Found"}
+	}
+	return &ret[0], nil
+}
+
+// GetGroupByName returns group by name, nil is returned if group does not exist
+func (c *Client) GetGroupByName(ctx context.Context, name string) (*Group, error) {
+	var ret []Group
+	if err := c.list(ctx, "groups", "", name, &ret); err != nil {
+		return nil, err
+	}
+	if len(ret) == 0 {
+		return nil, nil
+	}
+	return &ret[0], nil
+}
+
+// CreateGroup creates group and returns stored group
+func (c *Client) CreateGroup(ctx context.Context, group Group) (*Group, error) {
+	group.Id = ""
+	var ret Group
+	if err := c.do(ctx, http.MethodPost, "groups", "", "", &group, &ret); err != nil {
+		return nil, err
+	}
+	return &ret, nil
+}
+
+// UpdateGroup updates group identified by group.Id and returns stored group
+func (c *Client) UpdateGroup(ctx context.Context, group Group) (*Group, error) {
+	var ret Group
+	if err := c.do(ctx, http.MethodPut, "groups", group.Id, "", &group, &ret); err != nil {
+		return nil, err
+	}
+	return &ret, nil
+}
+
+// DeleteGroup deletes group by ID
+func (c *Client) DeleteGroup(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, "groups", id, "", nil, nil)
+}
@@ -1,4 +1,4 @@
-package main
+package client
 
 import (
 	"time"
@@ -6,12 +6,14 @@ import (
 	"github.com/golang-jwt/jwt/v4"
 )
 
+// JWTData are claims of token used for authentication of API calls
 type JWTData struct {
 	jwt.StandardClaims
 	ShieldooClaims map[string]string `json:"shieldoo"`
 }
 
-func GenerateJWTAccessToken(instance string) (string, error) {
+// GenerateJWTAccessToken generates token for shieldoo instance (domain of shieldoo URI) signed by API key
+func GenerateJWTAccessToken(instance string, apiKey string) (string, error) {
 	// prepare claims for token
 	claims := JWTData{
 		StandardClaims: jwt.StandardClaims{
@@ -27,7 +29,7 @@ func GenerateJWTAccessToken(instance string) (string, error) {
 	tokenString := jwt.NewWithClaims(jwt.SigningMethodHS512, claims)
 
 	// sign the generated key using secretKey
-	token, err := tokenString.SignedString([]byte(shieldooApiKey))
+	token, err := tokenString.SignedString([]byte(apiKey))
 
 	return token, err
 }
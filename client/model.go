@@ -0,0 +1,59 @@
+package client
+
+// Group is group of users and servers, reference to group in firewall rule or server
+// has set only one of Id, Name or ObjectId.
+// ObjectId is ID of group in directory (for example Azure AD) which group is synchronized with.
+type Group struct {
+	Id          string `json:"id" yaml:"id,omitempty"`
+	Name        string `json:"name" yaml:"name,omitempty"`
+	ObjectId    string `json:"objectId" yaml:"objectId,omitempty"`
+	Description string `json:"description" yaml:"description,omitempty"`
+}
+
+// FirewallRule allows traffic of protocol and port from or to any host or members of groups
+type FirewallRule struct {
+	Protocol string  `json:"protocol" yaml:"protocol"`
+	Port     string  `json:"port" yaml:"port"`
+	Host     string  `json:"host" yaml:"host"`
+	Groups   []Group `json:"groups" yaml:"groups,omitempty"`
+}
+
+// Firewall is named set of input and output rules assigned to servers
+type Firewall struct {
+	Id       string         `json:"id" yaml:"id,omitempty"`
+	Name     string         `json:"name" yaml:"name,omitempty"`
+	RulesIn  []FirewallRule `json:"rulesIn" yaml:"rulesIn,omitempty"`
+	RulesOut []FirewallRule `json:"rulesOut" yaml:"rulesOut,omitempty"`
+}
+
+// Listener forwards port of server to other host
+type Listener struct {
+	ListenPort  int    `json:"listenPort" yaml:"listenPort"`
+	Protocol    string `json:"protocol" yaml:"protocol"`
+	ForwardPort int    `json:"forwardPort" yaml:"forwardPort"`
+	ForwardHost string `json:"forwardHost" yaml:"forwardHost"`
+	Description string `json:"description" yaml:"description,omitempty"`
+}
+
+// Server is shieldoo server
+type Server struct {
+	Id             string                   `json:"id" yaml:"id,omitempty"`
+	Name           string                   `json:"name" yaml:"name"`
+	Groups         []Group                  `json:"groups" yaml:"groups,omitempty"`
+	Firewall       Firewall                 `json:"firewall" yaml:"firewall,omitempty"`
+	Listeners      []Listener               `json:"listeners" yaml:"listeners,omitempty"`
+	Autoupdate     bool                     `json:"autoupdate" yaml:"autoupdate,omitempty"`
+	IpAddress      string                   `json:"ipAddress" yaml:"ipAddress,omitempty"`
+	Description    string                   `json:"description" yaml:"description,omitempty"`
+	Configuration  string                   `json:"configuration" yaml:"configuration,omitempty"`
+	OSUpdatePolicy ServerOSAutoupdatePolicy `json:"osUpdatePolicy" yaml:"osUpdatePolicy,omitempty"`
+}
+
+// ServerOSAutoupdatePolicy configures OS updates of server
+type ServerOSAutoupdatePolicy struct {
+	Enabled                   bool `json:"enabled" yaml:"enabled,omitempty"`
+	SecurityAutoupdateEnabled bool `json:"securityAutoupdateEnabled" yaml:"securityAutoupdateEnabled,omitempty"`
+	AllAutoupdateEnabled      bool `json:"allAutoupdateEnabled" yaml:"allAutoupdateEnabled,omitempty"`
+	RestartAfterUpdate        bool `json:"restartAfterUpdate" yaml:"restartAfterUpdate,omitempty"`
+	UpdateHour                int  `json:"updateHour" yaml:"updateHour,omitempty"`
+}
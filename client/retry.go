@@ -0,0 +1,141 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy configures retries of failed requests.
+//
+// Requests are retried on connection errors, 429 Too Many Requests and 5xx responses
+// with jittered exponential backoff, Retry-After header is honoured. If server asks
+// for longer wait than MaxBackoff, request is not retried and its error is returned.
+// POST requests are not idempotent, so they are retried only if it is sure that
+// server did not process them (connection was not established or response was 429).
+type RetryPolicy struct {
+	// MaxRetries is maximum number of retries, 0 disables retries
+	MaxRetries int
+	// MinBackoff is wait time before first retry
+	MinBackoff time.Duration
+	// MaxBackoff is maximum wait time between retries
+	MaxBackoff time.Duration
+}
+
+// DefaultRetryPolicy is used by clients created by New
+var DefaultRetryPolicy = RetryPolicy{
+	MaxRetries: 3,
+	MinBackoff: 500 * time.Millisecond,
+	MaxBackoff: 30 * time.Second,
+}
+
+// DefaultTimeout is timeout of one HTTP request
+const DefaultTimeout = 30 * time.Second
+
+// Option configures client
+type Option func(c *Client)
+
+// WithTimeout sets timeout of one HTTP request (every retry has its own timeout)
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.httpClient.Timeout = timeout
+	}
+}
+
+// WithRetryPolicy sets retry policy
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *Client) {
+		c.retry = policy
+	}
+}
+
+// WithHTTPClient sets HTTP client used for requests
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+func isIdempotent(method string) bool {
+	return method != http.MethodPost && method != http.MethodPatch
+}
+
+// notSent checks if request failed before it was sent to server
+func notSent(err error) bool {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr)
+}
+
+// shouldRetry decides if request should be retried after response or error
+func shouldRetry(method string, resp *http.Response, err error) bool {
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			return false
+		}
+		return isIdempotent(method) || notSent(err)
+	}
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return true
+	case resp.StatusCode >= 500:
+		return isIdempotent(method)
+	}
+	return false
+}
+
+// backoff returns wait time before retry (attempt starts with 0),
+// false is returned if Retry-After of response is longer than MaxBackoff
+func (p RetryPolicy) backoff(attempt int, resp *http.Response) (time.Duration, bool) {
+	if resp != nil {
+		if d, ok := retryAfter(resp.Header.Get("Retry-After")); ok {
+			return d, d <= p.MaxBackoff
+		}
+	}
+	d := p.MinBackoff << uint(attempt)
+	if d <= 0 || d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	// equal jitter - half of wait time is random
+	half := d / 2
+	if half <= 0 {
+		return d, true
+	}
+	return half + time.Duration(rand.Int63n(int64(half))), true
+}
+
+// retryAfter parses Retry-After header (seconds or HTTP date)
+func retryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if sec, err := strconv.Atoi(value); err == nil && sec >= 0 {
+		return time.Duration(sec) * time.Second, true
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		d := time.Until(t)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+	return 0, false
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
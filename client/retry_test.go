@@ -0,0 +1,114 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	p := RetryPolicy{MaxRetries: 3, MinBackoff: 100 * time.Millisecond, MaxBackoff: 10 * time.Second}
+	tests := []struct {
+		name       string
+		attempt    int
+		retryAfter string
+		wantMin    time.Duration
+		wantMax    time.Duration
+		wantRetry  bool
+	}{
+		{name: "first attempt", attempt: 0, wantMin: 50 * time.Millisecond, wantMax: 100 * time.Millisecond, wantRetry: true},
+		{name: "third attempt", attempt: 2, wantMin: 200 * time.Millisecond, wantMax: 400 * time.Millisecond, wantRetry: true},
+		{name: "capped by MaxBackoff", attempt: 20, wantMin: 5 * time.Second, wantMax: 10 * time.Second, wantRetry: true},
+		{name: "overflow", attempt: 70, wantMin: 5 * time.Second, wantMax: 10 * time.Second, wantRetry: true},
+		{name: "Retry-After seconds", retryAfter: "2", wantMin: 2 * time.Second, wantMax: 2 * time.Second, wantRetry: true},
+		{name: "Retry-After equal to MaxBackoff", retryAfter: "10", wantMin: 10 * time.Second, wantMax: 10 * time.Second, wantRetry: true},
+		{name: "Retry-After longer than MaxBackoff", retryAfter: "3600", wantRetry: false},
+		{name: "Retry-After date longer than MaxBackoff", retryAfter: time.Now().Add(time.Hour).UTC().Format(http.TimeFormat), wantRetry: false},
+		{name: "Retry-After date in past", retryAfter: "Mon, 02 Jan 2006 15:04:05 GMT", wantMin: 0, wantMax: 0, wantRetry: true},
+		{name: "invalid Retry-After", retryAfter: "soon", attempt: 0, wantMin: 50 * time.Millisecond, wantMax: 100 * time.Millisecond, wantRetry: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{Header: http.Header{}}
+			if tt.retryAfter != "" {
+				resp.Header.Set("Retry-After", tt.retryAfter)
+			}
+			d, ok := p.backoff(tt.attempt, resp)
+			if ok != tt.wantRetry {
+				t.Fatalf("retry = %v, want %v", ok, tt.wantRetry)
+			}
+			if ok && (d < tt.wantMin || d > tt.wantMax) {
+				t.Errorf("backoff %s, want between %s and %s", d, tt.wantMin, tt.wantMax)
+			}
+		})
+	}
+}
+
+func TestRetries(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		status     int
+		retryAfter string
+		wantCalls  int32
+	}{
+		{name: "GET 503 is retried", method: http.MethodGet, status: http.StatusServiceUnavailable, wantCalls: 4},
+		{name: "POST 500 is not retried", method: http.MethodPost, status: http.StatusInternalServerError, wantCalls: 1},
+		{name: "POST 429 is retried", method: http.MethodPost, status: http.StatusTooManyRequests, wantCalls: 4},
+		{name: "long Retry-After is not waited", method: http.MethodGet, status: http.StatusTooManyRequests, retryAfter: "3600", wantCalls: 1},
+		{name: "404 is not retried", method: http.MethodGet, status: http.StatusNotFound, wantCalls: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&calls, 1)
+				if tt.retryAfter != "" {
+					w.Header().Set("Retry-After", tt.retryAfter)
+				}
+				w.WriteHeader(tt.status)
+			}))
+			defer srv.Close()
+			c := New(srv.URL, "test-key", WithRetryPolicy(RetryPolicy{MaxRetries: 3, MinBackoff: time.Millisecond, MaxBackoff: 10 * time.Millisecond}))
+
+			var err error
+			if tt.method == http.MethodPost {
+				_, err = c.CreateServer(context.Background(), Server{Name: "test"})
+			} else {
+				_, err = c.ListServers(context.Background())
+			}
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) || apiErr.StatusCode != tt.status {
+				t.Errorf("got error %v, want status %d", err, tt.status)
+			}
+			if got := atomic.LoadInt32(&calls); got != tt.wantCalls {
+				t.Errorf("server was called %d times, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestRetrySucceeds(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`[{"id":"1","name":"web-1"}]`))
+	}))
+	defer srv.Close()
+	c := New(srv.URL, "test-key")
+	servers, err := c.ListServers(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(servers) != 1 || servers[0].Name != "web-1" || calls != 2 {
+		t.Errorf("got %+v after %d calls, want web-1 after 2 calls", servers, calls)
+	}
+}
@@ -0,0 +1,61 @@
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+// ListServers returns all servers
+func (c *Client) ListServers(ctx context.Context) ([]Server, error) {
+	ret := []Server{}
+	err := c.list(ctx, "servers", "", "", &ret)
+	return ret, err
+}
+
+// GetServer returns server by ID, APIError with status 404 is returned if server does not exist
+func (c *Client) GetServer(ctx context.Context, id string) (*Server, error) {
+	var ret []Server
+	if err := c.list(ctx, "servers", id, "", &ret); err != nil {
+		return nil, err
+	}
+	if len(ret) == 0 {
+		return nil, &APIError{StatusCode: http.StatusNotFound, Status: "404 Not Found"}
+	}
+	return &ret[0], nil
+}
+
+// GetServerByName returns server by name, nil is returned if server does not exist
+func (c *Client) GetServerByName(ctx context.Context, name string) (*Server, error) {
+	var ret []Server
+	if err := c.list(ctx, "servers", "", name, &ret); err != nil {
+		return nil, err
+	}
+	if len(ret) == 0 {
+		return nil, nil
+	}
+	return &ret[0], nil
+}
+
+// CreateServer creates server and returns stored server
+func (c *Client) CreateServer(ctx context.Context, server Server) (*Server, error) {
+	server.Id = ""
+	var ret Server
+	if err := c.do(ctx, http.MethodPost, "servers", "", "", &server, &ret); err != nil {
+		return nil, err
+	}
+	return &ret, nil
+}
+
+// UpdateServer updates server identified by server.Id and returns stored server
+func (c *Client) UpdateServer(ctx context.Context, server Server) (*Server, error) {
+	var ret Server
+	if err := c.do(ctx, http.MethodPut, "servers", server.Id, "", &server, &ret); err != nil {
+		return nil, err
+	}
+	return &ret, nil
+}
+
+// DeleteServer deletes server by ID
+func (c *Client) DeleteServer(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, "servers", id, "", nil, nil)
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+func initApplyCmd() *cobra.Command {
+	addManifestFlags(applyCmd, "Manifest file (YAML or JSON) with firewalls and servers", true)
+	applyCmd.Flags().Bool("dry-run", false, "Only show changes, nothing is written (the same as plan command)")
+	addPruneFlags(applyCmd)
+	addStateFlag(applyCmd)
+	applyCmd.Flags().Duration("lock-timeout", 0, "How long to wait for state lock held by other process, by default apply fails immediately")
+	applyCmd.Flags().Bool("yes", false, "Do not ask for confirmation of deletions made by prune")
+	return applyCmd
+}
+
+var applyCmd = &cobra.Command{
+	Use:   "apply",
+	Short: "Apply manifest with firewalls and servers",
+	Long: "Apply manifest with firewalls and servers.\n" +
+		"Firewalls are created or updated first, then servers referencing them by firewall name or ID.",
+	Run: func(cmd *cobra.Command, args []string) {
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		statePath, _ := cmd.Flags().GetString("state")
+		lockTimeout, _ := cmd.Flags().GetDuration("lock-timeout")
+
+		m, loader, err := loadManifestFromFlags(cmd)
+		if err != nil {
+			fmt.Printf("ERROR: %s\n", err)
+			os.Exit(1)
+		}
+		prune := getPruneOptions(cmd, m)
+		// confirmation is read from standard input, which is already used by manifest
+		if prune != nil && !prune.Yes && !dryRun && loader.readsStdin() {
+			fmt.Printf("ERROR: prune can not be confirmed when manifest is read from standard input, use --yes\n")
+			os.Exit(1)
+		}
+		if dryRun {
+			var st *stateFile
+			if statePath != "" {
+				st, err = loadState(statePath)
+				if err != nil {
+					fmt.Printf("ERROR: %s\n", err)
+					os.Exit(1)
+				}
+			}
+			p, err := planManifest(m, prune, st)
+			if err != nil {
+				fmt.Printf("ERROR: %s\n", err)
+				os.Exit(1)
+			}
+			printPlan(os.Stdout, p)
+			return
+		}
+		var st *stateFile
+		if statePath != "" {
+			// state is locked during whole apply, so concurrent applies do not overwrite it
+			st, err = lockState(statePath, "apply", lockTimeout)
+			if err != nil {
+				fmt.Printf("ERROR: %s\n", err)
+				os.Exit(1)
+			}
+		}
+		err = applyManifest(m, prune, st)
+		if st != nil {
+			if uerr := st.unlock(); uerr != nil && err == nil {
+				err = uerr
+			}
+		}
+		if err != nil {
+			fmt.Printf("ERROR: %s\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// applyManifest creates or updates resources from manifest,
+// if prune options are set than resources not present in manifest are deleted,
+// if state is not nil than resources are found by IDs from state and state is saved
+// (also after failure, so already applied resources are tracked)
+func applyManifest(m *Manifest, prune *pruneOptions, st *stateFile) (err error) {
+	// all group references are checked before any change is made
+	if err := resolveManifestGroups(m); err != nil {
+		return err
+	}
+	l, err := newResourceLocator(st)
+	if err != nil {
+		return err
+	}
+	// deletions are checked before any change is made
+	var deletions []resourceChange
+	if prune != nil {
+		prune.Managed = l.managedIds(m)
+		deletions, err = planPrune(m, prune)
+		if err != nil {
+			return err
+		}
+		if err := checkPrune(deletions, prune, os.Stdin, os.Stdout); err != nil {
+			return err
+		}
+	}
+	if st != nil {
+		defer func() {
+			if serr := st.save(); serr != nil && err == nil {
+				err = serr
+			}
+		}()
+	}
+	// firewalls must exist before servers which reference them
+	fwIds := map[string]string{}
+	for _, fw := range m.Firewalls {
+		current, lost, err := l.firewall(fw)
+		if err != nil {
+			return fmt.Errorf("firewall '%s': %s", fw.Name, err)
+		}
+		applied, created, err := saveFirewall(fw.Firewall, current)
+		if err != nil {
+			return fmt.Errorf("firewall '%s': %s", fw.Name, err)
+		}
+		fwIds[fw.Name] = applied.Id
+		if st != nil {
+			st.Firewalls[fw.key()] = stateEntry{Id: applied.Id, Name: applied.Name}
+		}
+		fmt.Printf("firewall '%s' %s\n", fw.Name, appliedAction(created, lost))
+	}
+	for _, server := range m.Servers {
+		if server.Firewall.Id == "" {
+			server.Firewall.Id = fwIds[server.Firewall.Name]
+		}
+		current, lost, err := l.server(server)
+		if err != nil {
+			return fmt.Errorf("server '%s': %s", server.Name, err)
+		}
+		applied, created, err := saveServer(server.Server, current)
+		if err != nil {
+			return fmt.Errorf("server '%s': %s", server.Name, err)
+		}
+		if st != nil {
+			st.Servers[server.key()] = stateEntry{Id: applied.Id, Name: applied.Name}
+		}
+		fmt.Printf("server '%s' %s\n", server.Name, appliedAction(created, lost))
+	}
+	if err := deleteResources(deletions); err != nil {
+		return err
+	}
+	if st != nil {
+		st.retain(m)
+	}
+	return nil
+}
+
+func appliedAction(created bool, lost bool) string {
+	if lost {
+		return "created (deleted outside of manifest)"
+	}
+	if created {
+		return "created"
+	}
+	return "updated"
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Manage connection profiles (contexts)",
+	Long: "Manage connection profiles (contexts) stored in config file.\n" +
+		"Config file is ~/.config/shieldoo/config.yaml, path can be changed by SHIELDOO_CONFIG environment variable.\n" +
+		"Profile is selected by --profile flag, SHIELDOO_PROFILE environment variable or current context.\n" +
+		"SHIELDOO_URI and SHIELDOO_APIKEY environment variables override values from profile.",
+}
+
+func initConfigCmd() *cobra.Command {
+	configSetContextCmd.Flags().String("uri", "", "Shieldoo URI which you can find in shieldoo admin portal (required for new context)")
+	configSetContextCmd.Flags().String("apikey", "", "Shieldoo ApiKey which you can find in shieldoo admin portal")
+	configSetContextCmd.Flags().String("credential-process", "", "Command which prints ApiKey to standard output (example: vault kv get -field=apikey secret/shieldoo)")
+	configCmd.AddCommand(configSetContextCmd)
+
+	configCmd.AddCommand(configUseContextCmd)
+	configCmd.AddCommand(configGetContextsCmd)
+	configCmd.AddCommand(configCurrentContextCmd)
+
+	return configCmd
+}
+
+var configSetContextCmd = &cobra.Command{
+	Use:   "set-context NAME",
+	Short: "Create or update a context",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := loadConfig()
+		if err != nil {
+			fmt.Printf("ERROR: %s\n", err)
+			os.Exit(1)
+		}
+		ctx := cfg.findContext(args[0])
+		if ctx == nil {
+			cfg.Contexts = append(cfg.Contexts, configContext{Name: args[0]})
+			ctx = &cfg.Contexts[len(cfg.Contexts)-1]
+		}
+		if cmd.Flags().Changed("uri") {
+			ctx.Uri, _ = cmd.Flags().GetString("uri")
+		}
+		if cmd.Flags().Changed("apikey") {
+			ctx.ApiKey, _ = cmd.Flags().GetString("apikey")
+		}
+		if cmd.Flags().Changed("credential-process") {
+			ctx.CredentialProcess, _ = cmd.Flags().GetString("credential-process")
+		}
+		// new context requires URI and URI of existing context can not be removed
+		if ctx.Uri == "" {
+			fmt.Printf("ERROR: context '%s' has no URI, use --uri flag\n", ctx.Name)
+			os.Exit(1)
+		}
+		// first context is used by default
+		if cfg.CurrentContext == "" {
+			cfg.CurrentContext = ctx.Name
+		}
+		if err := saveConfig(cfg); err != nil {
+			fmt.Printf("ERROR: %s\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Context '%s' saved\n", args[0])
+	},
+}
+
+var configUseContextCmd = &cobra.Command{
+	Use:   "use-context NAME",
+	Short: "Set the current context",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := loadConfig()
+		if err != nil {
+			fmt.Printf("ERROR: %s\n", err)
+			os.Exit(1)
+		}
+		if cfg.findContext(args[0]) == nil {
+			fmt.Printf("ERROR: context '%s' not found\n", args[0])
+			os.Exit(1)
+		}
+		cfg.CurrentContext = args[0]
+		if err := saveConfig(cfg); err != nil {
+			fmt.Printf("ERROR: %s\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Switched to context '%s'\n", args[0])
+	},
+}
+
+var configGetContextsCmd = &cobra.Command{
+	Use:   "get-contexts",
+	Short: "List all contexts",
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := loadConfig()
+		if err != nil {
+			fmt.Printf("ERROR: %s\n", err)
+			os.Exit(1)
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+		fmt.Fprintln(tw, "CURRENT\tNAME\tURI")
+		for _, ctx := range cfg.Contexts {
+			current := ""
+			if ctx.Name == cfg.CurrentContext {
+				current = "*"
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", current, ctx.Name, ctx.Uri)
+		}
+		tw.Flush()
+	},
+}
+
+var configCurrentContextCmd = &cobra.Command{
+	Use:   "current-context",
+	Short: "Show the current context",
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := loadConfig()
+		if err != nil {
+			fmt.Printf("ERROR: %s\n", err)
+			os.Exit(1)
+		}
+		if cfg.CurrentContext == "" {
+			fmt.Printf("ERROR: current context is not set\n")
+			os.Exit(1)
+		}
+		fmt.Println(cfg.CurrentContext)
+	},
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/shieldoo/shieldoo-cli/client"
+	"github.com/shieldoo/shieldoo-cli/mockapi"
+	"github.com/spf13/cobra"
+)
+
+func initDevServerCmd() *cobra.Command {
+	devServerCmd.Flags().String("listen", "127.0.0.1:8080", "Address on which mock API listens")
+	devServerCmd.Flags().String("apikey", "", "ApiKey used to validate tokens (required)")
+	devServerCmd.Flags().String("instance", "", "Expected instance claim of tokens (host of SHIELDOO_URI), any instance is accepted if empty")
+	devServerCmd.Flags().StringSlice("group", []string{}, "Group created on startup, can be used multiple times")
+	devServerCmd.MarkFlagRequired("apikey")
+	return devServerCmd
+}
+
+var devServerCmd = &cobra.Command{
+	Use:   "dev-server",
+	Short: "Run offline mock of Shieldoo API",
+	Long: "Run offline mock of Shieldoo API for tests and local development.\n" +
+		"Objects are stored in memory only, tokens are validated the same way as by Shieldoo.",
+	Run: func(cmd *cobra.Command, args []string) {
+		listen, _ := cmd.Flags().GetString("listen")
+		apiKey, _ := cmd.Flags().GetString("apikey")
+		instance, _ := cmd.Flags().GetString("instance")
+		groups, _ := cmd.Flags().GetStringSlice("group")
+
+		api := mockapi.New(apiKey, instance)
+		for _, name := range groups {
+			api.AddGroup(client.Group{Name: name})
+		}
+		fmt.Printf("Mock API listening on http://%s\n", listen)
+		fmt.Printf("Use: SHIELDOO_URI=http://%s SHIELDOO_APIKEY=%s shieldoo ...\n", listen, apiKey)
+		if err := http.ListenAndServe(listen, api); err != nil {
+			fmt.Printf("ERROR: %s\n", err)
+			os.Exit(1)
+		}
+	},
+}
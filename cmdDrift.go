@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+func initDriftCmd() *cobra.Command {
+	addManifestFlags(driftCmd, "Manifest file (YAML or JSON) with firewalls and servers", true)
+	addStateFlag(driftCmd)
+	driftCmd.Flags().String("format", "text", "Report format: "+strings.Join(driftFormats, ", "))
+	driftCmd.Flags().Bool("unmanaged", false, "Report servers and firewalls which are not present in manifest as drift\n"+
+		"	(names from manifest 'protected' list are ignored)")
+	return driftCmd
+}
+
+var driftCmd = &cobra.Command{
+	Use:   "drift",
+	Short: "Detect differences between tenant and manifest",
+	Long: "Detect differences between tenant and manifest, nothing is written.\n" +
+		"Exit code is 0 if tenant matches manifest, 2 if drift is detected and 1 in case of error.\n" +
+		"Errors are written to stderr, so report on stdout stays valid.",
+	Run: func(cmd *cobra.Command, args []string) {
+		format, _ := cmd.Flags().GetString("format")
+		unmanaged, _ := cmd.Flags().GetBool("unmanaged")
+
+		if !isDriftFormat(format) {
+			fmt.Fprintf(os.Stderr, "ERROR: unknown format '%s', supported formats are %s\n", format, strings.Join(driftFormats, ", "))
+			os.Exit(1)
+		}
+		m, _, err := loadManifestFromFlags(cmd)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %s\n", err)
+			os.Exit(1)
+		}
+		var st *stateFile
+		if statePath, _ := cmd.Flags().GetString("state"); statePath != "" {
+			st, err = loadState(statePath)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "ERROR: %s\n", err)
+				os.Exit(1)
+			}
+		}
+		var prune *pruneOptions
+		if unmanaged {
+			prune = &pruneOptions{Protected: m.Protected}
+		}
+		p, err := planManifest(m, prune, st)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %s\n", err)
+			os.Exit(1)
+		}
+		if err := printDrift(os.Stdout, format, p); err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %s\n", err)
+			os.Exit(1)
+		}
+		if p.hasChanges() {
+			os.Exit(exitDrift)
+		}
+	},
+}
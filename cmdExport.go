@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+func initExportCmd() *cobra.Command {
+	exportCmd.Flags().String("output-file", "", "Output manifest file, manifest is printed to standard output if empty")
+	exportCmd.Flags().Bool("force", false, "Overwrite existing output manifest file")
+	return exportCmd
+}
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export firewalls and servers to manifest",
+	Long: "Export all firewalls and servers of tenant to manifest which can be used by apply command.\n" +
+		"Firewalls and groups are referenced by name, IDs and server configuration are not exported.\n" +
+		"Groups are not exported, manifest does not manage them, so they have to exist before apply.",
+	Run: func(cmd *cobra.Command, args []string) {
+		filename, _ := cmd.Flags().GetString("output-file")
+		force, _ := cmd.Flags().GetBool("force")
+
+		if filename != "" && !force {
+			if _, err := os.Stat(filename); err == nil {
+				fmt.Printf("ERROR: file %s already exists, use --force to overwrite it\n", filename)
+				os.Exit(1)
+			}
+		}
+		m, err := exportManifest()
+		if err != nil {
+			fmt.Printf("ERROR: %s\n", err)
+			os.Exit(1)
+		}
+		// manifest is rendered first, so existing file is not truncated if export fails
+		var out bytes.Buffer
+		if err := writeManifest(&out, m); err != nil {
+			fmt.Printf("ERROR: %s\n", err)
+			os.Exit(1)
+		}
+		if filename == "" {
+			os.Stdout.Write(out.Bytes())
+			return
+		}
+		if err := writeFileAtomic(filename, out.Bytes(), 0644); err != nil {
+			fmt.Printf("ERROR: %s\n", err)
+			os.Exit(1)
+		}
+	},
+}
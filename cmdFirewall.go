@@ -1,7 +1,6 @@
 package main
 
 import (
-	"encoding/json"
 	"fmt"
 	"os"
 	"strings"
@@ -31,15 +30,42 @@ func initFirewallCmd() *cobra.Command {
 		"	for IDs use format id=###, for name use format name=###, for objectId use format objectId=###\n"+
 		"Example:\n"+
 		"	any;any;any,tcp;22;group;demo.shieldoo.net:groups:1,udp;53;group:e7549a43-f3c2-4d0d-9cd1-6811a107cdc4;a3e4ead5-ffb7-4d94-ba71-0185b5466426")
+	firewallEnsureCmd.Flags().String("rules-file", "", "File with input and output firewall rules written in rule language, one rule per line\n"+
+		"	(can not be combined with --rules-in and --rules-out)\n"+
+		"Example:\n"+
+		"	# SSH for admins only\n"+
+		"	allow in tcp 22 from group \"admins\"\n"+
+		"	allow in udp 16000-16999 from any\n"+
+		"	allow out any to any")
+	firewallEnsureCmd.Flags().Bool("dry-run", false, "Only show changes, nothing is written")
 	firewallEnsureCmd.MarkFlagRequired("name")
+	firewallEnsureCmd.MarkFlagsMutuallyExclusive("rules-file", "rules-in")
+	firewallEnsureCmd.MarkFlagsMutuallyExclusive("rules-file", "rules-out")
 	firewallCmd.AddCommand(firewallEnsureCmd)
 
 	firewallDeleteCmd.Flags().String("id", "", "ID of the firewall rule to delete (required)")
+	firewallDeleteCmd.Flags().Bool("force", false, "Delete firewall even if it is used by servers")
 	firewallDeleteCmd.MarkFlagRequired("id")
 	firewallCmd.AddCommand(firewallDeleteCmd)
 
 	firewallCmd.AddCommand(firewallListCmd)
 
+	firewallUsagesCmd.Flags().String("name", "", "Name of the firewall, all firewalls are shown if name and id are empty")
+	firewallUsagesCmd.Flags().String("id", "", "ID of the firewall")
+	firewallCmd.AddCommand(firewallUsagesCmd)
+
+	addManifestFlags(firewallLintCmd, "Lint all firewalls from manifest file", false)
+	firewallLintCmd.Flags().String("name", "", "Name of the firewall to lint")
+	firewallLintCmd.Flags().String("id", "", "ID of the firewall to lint")
+	firewallLintCmd.Flags().String("rules-file", "", "Lint rules from file written in rule language")
+	firewallLintCmd.Flags().String("rules-in", "", "Lint input rules in format protocol;port;host;group-ids (the same as in ensure command)")
+	firewallLintCmd.Flags().String("rules-out", "", "Lint output rules in format protocol;port;host;group-ids (the same as in ensure command)")
+	firewallLintCmd.Flags().StringSlice("severity", nil, "Severity of check in format check=severity, severity is off, info, warning or error\n"+
+		"	(example: overlap=off,duplicate=error), checks: "+strings.Join(lintChecks(), ", "))
+	firewallLintCmd.Flags().String("fail-on", severityError, "Exit with code 2 if there is finding with this or higher severity (info, warning or error)")
+	firewallLintCmd.Flags().Bool("offline", false, "Do not check if referenced groups exist (no API call is made for manifest and rules)")
+	firewallCmd.AddCommand(firewallLintCmd)
+
 	firewallShowCmd.Flags().String("id", "", "ID of the firewall rule to show (required)")
 	firewallShowCmd.Flags().String("name", "", "Name of the firewall rule to show (required)")
 	firewallCmd.AddCommand(firewallShowCmd)
@@ -54,59 +80,53 @@ var firewallEnsureCmd = &cobra.Command{
 		name, _ := cmd.Flags().GetString("name")
 		rulesIn, _ := cmd.Flags().GetString("rules-in")
 		rulesOut, _ := cmd.Flags().GetString("rules-out")
+		rulesFile, _ := cmd.Flags().GetString("rules-file")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
 
 		// parse rules
-		rin, err := parseFirewallRules(rulesIn)
-		if err != nil {
-			fmt.Printf("ERROR: %s\n", err)
-			os.Exit(1)
-		}
-		rout, err := parseFirewallRules(rulesOut)
-		if err != nil {
-			fmt.Printf("ERROR: %s\n", err)
-			os.Exit(1)
-		}
-		// if out rules empty, create default
-		if len(rout) == 0 {
-			rout = append(rout, FirewallRule{
-				Protocol: "any",
-				Port:     "any",
-				Host:     "any",
-			})
+		var rin, rout []FirewallRule
+		var err error
+		if rulesFile != "" {
+			rin, rout, err = loadRulesFile(rulesFile)
+			if err != nil {
+				fmt.Printf("ERROR: %s\n", err)
+				os.Exit(1)
+			}
+		} else {
+			rin, err = parseFirewallRules(rulesIn)
+			if err != nil {
+				fmt.Printf("ERROR: %s\n", err)
+				os.Exit(1)
+			}
+			rout, err = parseFirewallRules(rulesOut)
+			if err != nil {
+				fmt.Printf("ERROR: %s\n", err)
+				os.Exit(1)
+			}
 		}
 		fw := Firewall{
 			Name:     name,
 			RulesIn:  rin,
 			RulesOut: rout,
 		}
-		// convert FW name to ID
-		fwDetailData, err := callApi("GET", "/firewalls", name, "", nil)
+		if dryRun {
+			rc, err := planFirewall(fw)
+			if err != nil {
+				fmt.Printf("ERROR: %s\n", err)
+				os.Exit(1)
+			}
+			printPlan(os.Stdout, &plan{Resources: []resourceChange{rc}})
+			return
+		}
+		ret, _, err := ensureFirewall(fw)
 		if err != nil {
 			fmt.Printf("ERROR: %s\n", err)
 			os.Exit(1)
 		}
-		var fwDetail []Firewall
-		err = json.Unmarshal([]byte(fwDetailData), &fwDetail)
-		if err != nil {
-			fmt.Printf("ERROR: %s (%s)\n", err, fwDetailData)
+		if err := printOutput(os.Stdout, *ret); err != nil {
+			fmt.Printf("ERROR: %s\n", err)
 			os.Exit(1)
 		}
-		if len(fwDetail) != 0 {
-			// update
-			fwDetailData, err = callApi("PUT", "firewalls", "", fwDetail[0].Id, &fw)
-			if err != nil {
-				fmt.Printf("ERROR: %s, %s\n", err, fwDetailData)
-				os.Exit(1)
-			}
-		} else {
-			// create
-			fwDetailData, err = callApi("POST", "firewalls", "", "", &fw)
-			if err != nil {
-				fmt.Printf("ERROR: %s, %s\n", err, fwDetailData)
-				os.Exit(1)
-			}
-		}
-		fmt.Println(fwDetailData)
 	},
 }
 
@@ -115,9 +135,24 @@ var firewallDeleteCmd = &cobra.Command{
 	Short: "Delete a firewall",
 	Run: func(cmd *cobra.Command, args []string) {
 		id, _ := cmd.Flags().GetString("id")
-		ret, err := callApi("DELETE", "firewalls", "", id, nil)
-		if err != nil {
-			fmt.Printf("ERROR: %s - %s\n", err.Error(), ret)
+		force, _ := cmd.Flags().GetBool("force")
+		if !force {
+			t, err := loadTenantSnapshot()
+			if err != nil {
+				fmt.Printf("ERROR: %s\n", err)
+				os.Exit(1)
+			}
+			if servers := t.firewallServers(id); len(servers) > 0 {
+				var names []string
+				for _, s := range servers {
+					names = append(names, s.Name)
+				}
+				fmt.Printf("ERROR: firewall is used by servers: %s (use --force to delete it anyway)\n", strings.Join(names, ", "))
+				os.Exit(1)
+			}
+		}
+		if err := deleteFirewall(id); err != nil {
+			fmt.Printf("ERROR: %s\n", err.Error())
 			os.Exit(1)
 		}
 		fmt.Println("Firewall deleted")
@@ -128,12 +163,15 @@ var firewallListCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List firewalls",
 	Run: func(cmd *cobra.Command, args []string) {
-		ret, err := callApi("GET", "firewalls", "", "", nil)
+		ret, err := listFirewalls()
 		if err != nil {
-			fmt.Printf("ERROR: ", err.Error())
+			fmt.Printf("ERROR: %s\n", err.Error())
+			os.Exit(1)
+		}
+		if err := printOutput(os.Stdout, ret); err != nil {
+			fmt.Printf("ERROR: %s\n", err.Error())
 			os.Exit(1)
 		}
-		fmt.Println(ret)
 	},
 }
 
@@ -147,20 +185,145 @@ var firewallShowCmd = &cobra.Command{
 			fmt.Printf("Error: either name or id must be specified\n")
 			os.Exit(1)
 		}
-		ret, err := callApi("GET", "firewalls", name, id, nil)
+		ret, err := getFirewall(name, id)
 		if err != nil {
-			fmt.Printf("ERROR: %s - %s\n", err.Error(), ret)
+			fmt.Printf("ERROR: %s\n", err.Error())
 			os.Exit(1)
 		}
-		if ret == "" || strings.TrimSpace(ret) == "null" {
+		if ret == nil {
 			fmt.Printf("Firewall not found\n")
 			os.Exit(1)
 		}
-		// if it is json array than clean it up
-		if strings.HasPrefix(ret, "[") {
-			ret = strings.TrimPrefix(ret, "[")
-			ret = strings.TrimSuffix(ret, "]")
+		if err := printOutput(os.Stdout, *ret); err != nil {
+			fmt.Printf("ERROR: %s\n", err.Error())
+			os.Exit(1)
+		}
+	},
+}
+
+// lintedFirewalls returns firewalls selected by lint command flags,
+// all firewalls of tenant are returned if no source is specified
+func lintedFirewalls(cmd *cobra.Command) ([]Firewall, error) {
+	filenames, _ := cmd.Flags().GetStringSlice("filename")
+	name, _ := cmd.Flags().GetString("name")
+	id, _ := cmd.Flags().GetString("id")
+	rulesFile, _ := cmd.Flags().GetString("rules-file")
+	rulesIn, _ := cmd.Flags().GetString("rules-in")
+	rulesOut, _ := cmd.Flags().GetString("rules-out")
+
+	switch {
+	case len(filenames) > 0:
+		m, _, err := loadManifestFromFlags(cmd)
+		if err != nil {
+			return nil, err
+		}
+		return m.firewalls(), nil
+	case rulesFile != "":
+		rin, rout, err := loadRulesFile(rulesFile)
+		if err != nil {
+			return nil, err
+		}
+		if name == "" {
+			name = rulesFile
+		}
+		return []Firewall{{Name: name, RulesIn: rin, RulesOut: rout}}, nil
+	case rulesIn != "" || rulesOut != "":
+		rin, err := parseFirewallRules(rulesIn)
+		if err != nil {
+			return nil, err
+		}
+		rout, err := parseFirewallRules(rulesOut)
+		if err != nil {
+			return nil, err
+		}
+		if name == "" {
+			name = "(command line)"
+		}
+		return []Firewall{{Name: name, RulesIn: rin, RulesOut: rout}}, nil
+	case name != "" || id != "":
+		fw, err := getFirewall(name, id)
+		if err != nil {
+			return nil, err
+		}
+		if fw == nil {
+			return nil, fmt.Errorf("firewall not found")
+		}
+		return []Firewall{*fw}, nil
+	}
+	return listFirewalls()
+}
+
+var firewallLintCmd = &cobra.Command{
+	Use:   "lint",
+	Short: "Check firewall rules for problems",
+	Long: "Check firewall rules for problems like duplicate or shadowed rules, overlapping port ranges,\n" +
+		"inbound any;any;any rules, group rules without groups, unknown groups and inverted port ranges.\n" +
+		"Rules are read from manifest, rules file, flags or tenant (all firewalls if nothing is specified).\n" +
+		"Exit code is 0 if there is no finding with --fail-on or higher severity, 2 if there is one and 1 in case of error.",
+	Run: func(cmd *cobra.Command, args []string) {
+		severities, _ := cmd.Flags().GetStringSlice("severity")
+		failOn, _ := cmd.Flags().GetString("fail-on")
+		offline, _ := cmd.Flags().GetBool("offline")
+
+		l := newLinter()
+		if err := l.setSeverities(severities); err != nil {
+			fmt.Printf("ERROR: %s\n", err)
+			os.Exit(1)
+		}
+		if level, ok := severityLevels[failOn]; !ok || level == 0 {
+			fmt.Printf("ERROR: invalid --fail-on value '%s', use info, warning or error\n", failOn)
+			os.Exit(1)
+		}
+		firewalls, err := lintedFirewalls(cmd)
+		if err != nil {
+			fmt.Printf("ERROR: %s\n", err)
+			os.Exit(1)
+		}
+		if !offline {
+			l.groups, err = listGroups()
+			if err != nil {
+				fmt.Printf("ERROR: %s\n", err)
+				os.Exit(1)
+			}
+			if l.groups == nil {
+				l.groups = []Group{}
+			}
+		}
+		for _, fw := range firewalls {
+			l.lintFirewall(fw)
+		}
+		l.print(os.Stdout)
+		if l.failed(failOn) {
+			os.Exit(exitLintFindings)
+		}
+	},
+}
+
+var firewallUsagesCmd = &cobra.Command{
+	Use:   "usages",
+	Short: "Show servers which use a firewall",
+	Run: func(cmd *cobra.Command, args []string) {
+		name, _ := cmd.Flags().GetString("name")
+		id, _ := cmd.Flags().GetString("id")
+
+		t, err := loadTenantSnapshot()
+		if err != nil {
+			fmt.Printf("ERROR: %s\n", err)
+			os.Exit(1)
+		}
+		ret := []firewallUsages{}
+		for _, fw := range t.firewalls {
+			if (name == "" || fw.Name == name) && (id == "" || fw.Id == id) {
+				ret = append(ret, t.firewallUsages(fw))
+			}
+		}
+		if len(ret) == 0 && (name != "" || id != "") {
+			fmt.Printf("Firewall not found\n")
+			os.Exit(1)
+		}
+		if err := printOutput(os.Stdout, ret); err != nil {
+			fmt.Printf("ERROR: %s\n", err)
+			os.Exit(1)
 		}
-		fmt.Println(ret)
 	},
 }
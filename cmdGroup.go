@@ -3,7 +3,6 @@ package main
 import (
 	"fmt"
 	"os"
-	"strings"
 
 	"github.com/spf13/cobra"
 )
@@ -20,6 +19,22 @@ func initGroupCmd() *cobra.Command {
 	groupShowCmd.Flags().String("id", "", "Id of the group to show")
 	groupCmd.AddCommand(groupShowCmd)
 
+	groupEnsureCmd.Flags().String("name", "", "Name of the group (required)")
+	groupEnsureCmd.Flags().String("description", "", "Description of the group, current description is kept if not specified (optional)")
+	groupEnsureCmd.Flags().String("object-id", "", "ID of group in directory which group is synchronized with,\n"+
+		"	current objectId is kept if not specified, empty value removes it (optional)")
+	groupEnsureCmd.Flags().Bool("dry-run", false, "Only show changes, nothing is written")
+	groupEnsureCmd.MarkFlagRequired("name")
+	groupCmd.AddCommand(groupEnsureCmd)
+
+	groupDeleteCmd.Flags().String("id", "", "ID of the group to delete (required)")
+	groupDeleteCmd.MarkFlagRequired("id")
+	groupCmd.AddCommand(groupDeleteCmd)
+
+	groupUsagesCmd.Flags().String("name", "", "Name of the group, all groups are shown if name and id are empty")
+	groupUsagesCmd.Flags().String("id", "", "ID of the group")
+	groupCmd.AddCommand(groupUsagesCmd)
+
 	return groupCmd
 }
 
@@ -27,12 +42,15 @@ var groupListCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List all groups",
 	Run: func(cmd *cobra.Command, args []string) {
-		ret, err := callApi("GET", "groups", "", "", nil)
+		ret, err := listGroups()
 		if err != nil {
-			fmt.Printf("ERROR: ", err.Error())
+			fmt.Printf("ERROR: %s\n", err.Error())
+			os.Exit(1)
+		}
+		if err := printOutput(os.Stdout, ret); err != nil {
+			fmt.Printf("ERROR: %s\n", err.Error())
 			os.Exit(1)
 		}
-		fmt.Println(ret)
 	},
 }
 
@@ -46,15 +64,116 @@ var groupShowCmd = &cobra.Command{
 			fmt.Printf("Error: either name or id must be specified\n")
 			os.Exit(1)
 		}
-		ret, err := callApi("GET", "groups", name, id, nil)
+		ret, err := getGroup(name, id)
 		if err != nil {
-			fmt.Printf("ERROR: %s - %s\n", err.Error(), ret)
+			fmt.Printf("ERROR: %s\n", err.Error())
 			os.Exit(1)
 		}
-		if ret == "" || strings.TrimSpace(ret) == "null" {
+		if ret == nil {
 			fmt.Printf("Group not found\n")
 			os.Exit(1)
 		}
-		fmt.Println(ret)
+		if err := printOutput(os.Stdout, *ret); err != nil {
+			fmt.Printf("ERROR: %s\n", err.Error())
+			os.Exit(1)
+		}
+	},
+}
+
+var groupEnsureCmd = &cobra.Command{
+	Use:   "ensure",
+	Short: "Ensure a group (create or update)",
+	Run: func(cmd *cobra.Command, args []string) {
+		name, _ := cmd.Flags().GetString("name")
+		description, _ := cmd.Flags().GetString("description")
+		objectId, _ := cmd.Flags().GetString("object-id")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		g := Group{
+			Name:        name,
+			Description: description,
+			ObjectId:    objectId,
+		}
+		// fields which are not specified keep current values, so link to directory is not lost
+		if !cmd.Flags().Changed("description") || !cmd.Flags().Changed("object-id") {
+			current, err := getGroup(name, "")
+			if err != nil {
+				fmt.Printf("ERROR: %s\n", err)
+				os.Exit(1)
+			}
+			if current != nil && !cmd.Flags().Changed("description") {
+				g.Description = current.Description
+			}
+			if current != nil && !cmd.Flags().Changed("object-id") {
+				g.ObjectId = current.ObjectId
+			}
+		}
+		if dryRun {
+			rc, err := planGroup(g)
+			if err != nil {
+				fmt.Printf("ERROR: %s\n", err)
+				os.Exit(1)
+			}
+			printPlan(os.Stdout, &plan{Resources: []resourceChange{rc}})
+			return
+		}
+		ret, _, err := ensureGroup(g)
+		if err != nil {
+			fmt.Printf("ERROR: %s\n", err)
+			os.Exit(1)
+		}
+		if err := printOutput(os.Stdout, *ret); err != nil {
+			fmt.Printf("ERROR: %s\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var groupDeleteCmd = &cobra.Command{
+	Use:   "delete",
+	Short: "Delete a group",
+	Run: func(cmd *cobra.Command, args []string) {
+		id, _ := cmd.Flags().GetString("id")
+		if err := deleteGroup(id); err != nil {
+			fmt.Printf("ERROR: %s\n", err.Error())
+			os.Exit(1)
+		}
+		fmt.Println("Group deleted")
+	},
+}
+
+var groupUsagesCmd = &cobra.Command{
+	Use:   "usages",
+	Short: "Show servers and firewall rules which use a group",
+	Long: "Show servers which are members of a group and firewall rules which reference it,\n" +
+		"together with servers which use firewalls of these rules.",
+	Run: func(cmd *cobra.Command, args []string) {
+		name, _ := cmd.Flags().GetString("name")
+		id, _ := cmd.Flags().GetString("id")
+
+		groups, err := listGroups()
+		if err != nil {
+			fmt.Printf("ERROR: %s\n", err)
+			os.Exit(1)
+		}
+		t, err := loadTenantSnapshot()
+		if err != nil {
+			fmt.Printf("ERROR: %s\n", err)
+			os.Exit(1)
+		}
+		ret := []groupUsages{}
+		for _, g := range groups {
+			if (name == "" || g.Name == name) && (id == "" || g.Id == id) {
+				ret = append(ret, t.groupUsages(g))
+			}
+		}
+		if len(ret) == 0 && (name != "" || id != "") {
+			fmt.Printf("Group not found\n")
+			os.Exit(1)
+		}
+		if err := printOutput(os.Stdout, ret); err != nil {
+			fmt.Printf("ERROR: %s\n", err)
+			os.Exit(1)
+		}
 	},
 }
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Import existing firewall or server into state",
+	Long: "Bind existing firewall or server to manifest key in state, so it is managed by manifest\n" +
+		"without being recreated. Manifest snippet of imported object is printed, it has to be added\n" +
+		"to manifest (for example as new file in manifest directory) before next apply.",
+}
+
+func initImportCmd() *cobra.Command {
+	importCmd.PersistentFlags().String("state", "", "State location: local path, http(s)://... URL or s3://bucket/key (required)")
+	importCmd.MarkPersistentFlagRequired("state")
+	importCmd.PersistentFlags().String("key", "", "Manifest key of imported object, name is used if empty")
+	importCmd.PersistentFlags().Duration("lock-timeout", 0, "How long to wait for state lock held by other process")
+	// no -f shorthand, it means input manifest in other commands
+	importCmd.PersistentFlags().String("output-file", "", "Output manifest file, manifest snippet is printed to standard output if empty")
+	importCmd.PersistentFlags().Bool("force", false, "Overwrite existing output manifest file")
+	importCmd.AddCommand(importFirewallCmd)
+	importCmd.AddCommand(importServerCmd)
+	return importCmd
+}
+
+var importFirewallCmd = &cobra.Command{
+	Use:   "firewall ID",
+	Short: "Import existing firewall into state",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runImport(cmd, "firewall", args[0], importFirewall)
+	},
+}
+
+var importServerCmd = &cobra.Command{
+	Use:   "server ID",
+	Short: "Import existing server into state",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runImport(cmd, "server", args[0], importServer)
+	},
+}
+
+func runImport(cmd *cobra.Command, kind string, id string, importFunc func(*stateFile, string, string) (*importSnippet, error)) {
+	location, _ := cmd.Flags().GetString("state")
+	key, _ := cmd.Flags().GetString("key")
+	lockTimeout, _ := cmd.Flags().GetDuration("lock-timeout")
+	filename, _ := cmd.Flags().GetString("output-file")
+	force, _ := cmd.Flags().GetBool("force")
+
+	if filename != "" && !force {
+		if _, err := os.Stat(filename); err == nil {
+			fmt.Printf("ERROR: file %s already exists, use --force to overwrite it\n", filename)
+			os.Exit(1)
+		}
+	}
+
+	st, err := lockState(location, "import", lockTimeout)
+	if err != nil {
+		fmt.Printf("ERROR: %s\n", err)
+		os.Exit(1)
+	}
+	snippet, err := importFunc(st, id, key)
+	var out bytes.Buffer
+	if err == nil {
+		err = writeManifest(&out, snippet)
+	}
+	// snippet file is written before state is saved, so import is not recorded if snippet can not be written
+	if err == nil && filename != "" {
+		err = writeFileAtomic(filename, out.Bytes(), 0644)
+	}
+	if err == nil {
+		err = st.save()
+	}
+	if uerr := st.unlock(); uerr != nil && err == nil {
+		err = uerr
+	}
+	if err != nil {
+		fmt.Printf("ERROR: %s\n", err)
+		os.Exit(1)
+	}
+	if filename == "" {
+		os.Stdout.Write(out.Bytes())
+		return
+	}
+	fmt.Printf("Imported %s %s, manifest snippet written to %s\n", kind, id, filename)
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+func initLoginCmd() *cobra.Command {
+	loginCmd.Flags().String("uri", "", "Shieldoo URI which you can find in shieldoo admin portal (required for new profile)")
+	loginCmd.Flags().String("store", "", "Credential store: keyring (Secret Service or macOS Keychain) or file (passphrase-encrypted file),\n"+
+		"	keyring is used if it is available")
+	loginCmd.Flags().Bool("apikey-stdin", false, "Read ApiKey from standard input instead of prompt")
+	return loginCmd
+}
+
+func initLogoutCmd() *cobra.Command {
+	return logoutCmd
+}
+
+func readApiKey(fromStdin bool) (string, error) {
+	var key string
+	if fromStdin || !term.IsTerminal(int(os.Stdin.Fd())) {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return "", err
+		}
+		key = line
+	} else {
+		fmt.Fprint(os.Stderr, "ApiKey: ")
+		data, err := term.ReadPassword(int(os.Stdin.Fd()))
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", err
+		}
+		key = string(data)
+	}
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return "", errors.New("empty ApiKey")
+	}
+	return key, nil
+}
+
+var loginCmd = &cobra.Command{
+	Use:   "login",
+	Short: "Store ApiKey of profile in credential store",
+	Long: "Store ApiKey of profile in credential store.\n" +
+		"Profile is selected by --profile flag, SHIELDOO_PROFILE environment variable or current context,\n" +
+		"new profile is created if it does not exist. ApiKey is removed from config file.",
+	Run: func(cmd *cobra.Command, args []string) {
+		uri, _ := cmd.Flags().GetString("uri")
+		storeKind, _ := cmd.Flags().GetString("store")
+		apiKeyStdin, _ := cmd.Flags().GetBool("apikey-stdin")
+
+		cfg, err := loadConfig()
+		if err != nil {
+			fmt.Printf("ERROR: %s\n", err)
+			os.Exit(1)
+		}
+		name := selectedProfile(cfg)
+		if name == "" {
+			fmt.Printf("Error: profile is not selected, use --profile flag\n")
+			os.Exit(1)
+		}
+		ctx := cfg.findContext(name)
+		if ctx == nil {
+			cfg.Contexts = append(cfg.Contexts, configContext{Name: name})
+			ctx = &cfg.Contexts[len(cfg.Contexts)-1]
+		}
+		if uri != "" {
+			ctx.Uri = uri
+		}
+		if ctx.Uri == "" {
+			fmt.Printf("Error: profile '%s' has no URI, use --uri flag\n", name)
+			os.Exit(1)
+		}
+		if storeKind == "" {
+			storeKind = defaultCredentialStore()
+		}
+		store, err := newCredentialStore(storeKind)
+		if err != nil {
+			fmt.Printf("ERROR: %s\n", err)
+			os.Exit(1)
+		}
+		apiKey, err := readApiKey(apiKeyStdin)
+		if err != nil {
+			fmt.Printf("ERROR: %s\n", err)
+			os.Exit(1)
+		}
+		if err := store.Set(name, apiKey); err != nil {
+			fmt.Printf("ERROR: %s\n", err)
+			os.Exit(1)
+		}
+		ctx.ApiKey = ""
+		ctx.CredentialStore = storeKind
+		if cfg.CurrentContext == "" {
+			cfg.CurrentContext = name
+		}
+		if err := saveConfig(cfg); err != nil {
+			fmt.Printf("ERROR: %s\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("ApiKey of profile '%s' stored in %s\n", name, storeKind)
+	},
+}
+
+var logoutCmd = &cobra.Command{
+	Use:   "logout",
+	Short: "Remove ApiKey of profile from credential store",
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := loadConfig()
+		if err != nil {
+			fmt.Printf("ERROR: %s\n", err)
+			os.Exit(1)
+		}
+		name := selectedProfile(cfg)
+		ctx := cfg.findContext(name)
+		if ctx == nil {
+			fmt.Printf("ERROR: profile '%s' not found in config\n", name)
+			os.Exit(1)
+		}
+		if ctx.CredentialStore != "" {
+			store, err := newCredentialStore(ctx.CredentialStore)
+			if err != nil {
+				fmt.Printf("ERROR: %s\n", err)
+				os.Exit(1)
+			}
+			if err := store.Delete(name); err != nil {
+				fmt.Printf("ERROR: %s\n", err)
+				os.Exit(1)
+			}
+		}
+		ctx.ApiKey = ""
+		ctx.CredentialStore = ""
+		if err := saveConfig(cfg); err != nil {
+			fmt.Printf("ERROR: %s\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("ApiKey of profile '%s' removed\n", name)
+	},
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+func initNormalizeCmd() *cobra.Command {
+	addManifestFlags(normalizeCmd, "Manifest file (YAML or JSON) with firewalls and servers", true)
+	normalizeCmd.Flags().Bool("in-place", false, "Rewrite manifest file instead of printing normalized manifest to standard output")
+	return normalizeCmd
+}
+
+var normalizeCmd = &cobra.Command{
+	Use:   "normalize",
+	Short: "Replace group references in manifest by group IDs",
+	Long: "Replace group references (names and objectIds) in manifest by canonical group IDs.\n" +
+		"All group references are checked, unknown and ambiguous groups are reported.\n" +
+		"Normalized manifest is written as YAML, comments are not preserved.",
+	Run: func(cmd *cobra.Command, args []string) {
+		inPlace, _ := cmd.Flags().GetBool("in-place")
+
+		m, loader, err := loadManifestFromFlags(cmd)
+		if err != nil {
+			fmt.Printf("ERROR: %s\n", err)
+			os.Exit(1)
+		}
+		// rewriting of merged or templated manifest would lose its structure or variables
+		if inPlace && (len(loader.files) != 1 || loader.readsStdin() || loader.substituted) {
+			fmt.Printf("ERROR: --in-place can be used only with one manifest file without variables\n")
+			os.Exit(1)
+		}
+		if err := normalizeManifestGroups(m); err != nil {
+			fmt.Printf("ERROR: %s\n", err)
+			os.Exit(1)
+		}
+		if !inPlace {
+			if err := writeManifest(os.Stdout, m); err != nil {
+				fmt.Printf("ERROR: %s\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+		// manifest is replaced only after normalized content is complete
+		var buf bytes.Buffer
+		if err := writeManifest(&buf, m); err != nil {
+			fmt.Printf("ERROR: %s\n", err)
+			os.Exit(1)
+		}
+		path := loader.files[0]
+		fi, err := os.Stat(path)
+		if err != nil {
+			fmt.Printf("ERROR: %s\n", err)
+			os.Exit(1)
+		}
+		if err := writeFileAtomic(path, buf.Bytes(), fi.Mode().Perm()); err != nil {
+			fmt.Printf("ERROR: %s\n", err)
+			os.Exit(1)
+		}
+	},
+}
@@ -29,6 +29,23 @@ func parseGroup(data string) (Group, error) {
 	return mygroup, nil
 }
 
+// parseGroups parses comma separated group references in format used by parseGroup
+func parseGroups(groups string) ([]Group, error) {
+	var ret []Group
+	for _, group := range strings.Split(groups, ",") {
+		group = strings.TrimSpace(group)
+		if group == "" {
+			continue
+		}
+		g, err := parseGroup(group)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, g)
+	}
+	return ret, nil
+}
+
 func parseListeners(listeners string) ([]Listener, error) {
 	var mylisteners []Listener
 	// Listeners (comma separated) - list of listeners in format ListenerPort;Protocol;ForwardPort;ForwardHost;Description
@@ -62,11 +79,8 @@ func parseListeners(listeners string) ([]Listener, error) {
 			ForwardHost: parts[3],
 			Description: desc,
 		}
-		if !regexp.MustCompile(`^(tcp|udp)$`).MatchString(mylistener.Protocol) {
-			return nil, fmt.Errorf("invalid protocol: %s", mylistener.Protocol)
-		}
-		if mylistener.ForwardHost == "" {
-			return nil, fmt.Errorf("invalid forward host: %s", mylistener.ForwardHost)
+		if err := validateListener(mylistener); err != nil {
+			return nil, err
 		}
 		mylisteners = append(mylisteners, mylistener)
 	}
@@ -95,14 +109,8 @@ func parseFirewallRules(groups string) ([]FirewallRule, error) {
 			Host:     parts[2],
 		}
 		// validate data
-		if regexp.MustCompile(`^(any|icmp|tcp|udp)$`).MatchString(myrule.Protocol) == false {
-			return nil, fmt.Errorf("invalid protocol: %s", myrule.Protocol)
-		}
-		if regexp.MustCompile(`^([1-9][0-9]{0,3}|[1-5][0-9]{4}|6[0-4][0-9]{3}|65[0-4][0-9]{2}|655[0-2][0-9]|6553[0-5])$|^([1-9][0-9]{0,3}|[1-5][0-9]{4}|6[0-4][0-9]{3}|65[0-4][0-9]{2}|655[0-2][0-9]|6553[0-5])-([1-9][0-9]{0,3}|[1-5][0-9]{4}|6[0-4][0-9]{3}|65[0-4][0-9]{2}|655[0-2][0-9]|6553[0-5])$|^any$`).MatchString(myrule.Port) == false {
-			return nil, fmt.Errorf("invalid port: %s", myrule.Port)
-		}
-		if regexp.MustCompile(`^(any|group)$`).MatchString(myrule.Host) == false {
-			return nil, fmt.Errorf("invalid host: %s", myrule.Host)
+		if err := validateFirewallRule(myrule); err != nil {
+			return nil, err
 		}
 		// parse group
 		for _, g := range parts[3:] {
@@ -123,3 +131,81 @@ func parseFirewallRules(groups string) ([]FirewallRule, error) {
 	}
 	return rules, nil
 }
+
+func validateListener(l Listener) error {
+	if l.ListenPort < 1 || l.ListenPort > 65535 {
+		return fmt.Errorf("invalid listener port: %d", l.ListenPort)
+	}
+	if l.ForwardPort < 1 || l.ForwardPort > 65535 {
+		return fmt.Errorf("invalid forward port: %d", l.ForwardPort)
+	}
+	if !regexp.MustCompile(`^(tcp|udp)$`).MatchString(l.Protocol) {
+		return fmt.Errorf("invalid protocol: %s", l.Protocol)
+	}
+	if l.ForwardHost == "" {
+		return fmt.Errorf("invalid forward host: %s", l.ForwardHost)
+	}
+	return nil
+}
+
+// validateUpdateHour validates hour (GMT) of OS updates, 0 means any time
+func validateUpdateHour(hour int) error {
+	if hour < 0 || hour > 23 {
+		return fmt.Errorf("invalid OS update hour: %d (expected 0-23)", hour)
+	}
+	return nil
+}
+
+// validateFirewallRule validates protocol, port and host of rule, groups are not validated
+func validateFirewallRule(r FirewallRule) error {
+	if regexp.MustCompile(`^(any|icmp|tcp|udp)$`).MatchString(r.Protocol) == false {
+		return fmt.Errorf("invalid protocol: %s", r.Protocol)
+	}
+	if regexp.MustCompile(`^([1-9][0-9]{0,3}|[1-5][0-9]{4}|6[0-4][0-9]{3}|65[0-4][0-9]{2}|655[0-2][0-9]|6553[0-5])$|^([1-9][0-9]{0,3}|[1-5][0-9]{4}|6[0-4][0-9]{3}|65[0-4][0-9]{2}|655[0-2][0-9]|6553[0-5])-([1-9][0-9]{0,3}|[1-5][0-9]{4}|6[0-4][0-9]{3}|65[0-4][0-9]{2}|655[0-2][0-9]|6553[0-5])$|^any$`).MatchString(r.Port) == false {
+		return fmt.Errorf("invalid port: %s", r.Port)
+	}
+	if regexp.MustCompile(`^(any|group)$`).MatchString(r.Host) == false {
+		return fmt.Errorf("invalid host: %s", r.Host)
+	}
+	return nil
+}
+
+// formatGroup renders group in format used by parseGroup, name is preferred
+func formatGroup(g Group) string {
+	switch {
+	case g.Name != "":
+		return "name=" + g.Name
+	case g.Id != "":
+		return "id=" + g.Id
+	case g.ObjectId != "":
+		return "objectId=" + g.ObjectId
+	}
+	return ""
+}
+
+// formatFirewallRule renders rule in format used by parseFirewallRules
+func formatFirewallRule(r FirewallRule) string {
+	parts := []string{r.Protocol, r.Port, r.Host}
+	for _, g := range r.Groups {
+		parts = append(parts, formatGroup(g))
+	}
+	return strings.Join(parts, ";")
+}
+
+// formatListener renders listener in format used by parseListeners
+func formatListener(l Listener) string {
+	ret := fmt.Sprintf("%d;%s;%d;%s", l.ListenPort, l.Protocol, l.ForwardPort, l.ForwardHost)
+	if l.Description != "" {
+		ret += ";" + l.Description
+	}
+	return ret
+}
+
+// formatFirewallRules renders rules in format used by parseFirewallRules
+func formatFirewallRules(rules []FirewallRule) string {
+	var ret []string
+	for _, r := range rules {
+		ret = append(ret, formatFirewallRule(r))
+	}
+	return strings.Join(ret, ",")
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+func initPlanCmd() *cobra.Command {
+	addManifestFlags(planCmd, "Manifest file (YAML or JSON) with firewalls and servers", true)
+	addStateFlag(planCmd)
+	addPruneFlags(planCmd)
+	return planCmd
+}
+
+var planCmd = &cobra.Command{
+	Use:   "plan",
+	Short: "Show changes required by manifest",
+	Long: "Show changes required by manifest.\n" +
+		"Current firewalls and servers are compared with manifest and changes are printed, nothing is written.",
+	Run: func(cmd *cobra.Command, args []string) {
+
+		m, _, err := loadManifestFromFlags(cmd)
+		if err != nil {
+			fmt.Printf("ERROR: %s\n", err)
+			os.Exit(1)
+		}
+		var st *stateFile
+		if statePath, _ := cmd.Flags().GetString("state"); statePath != "" {
+			st, err = loadState(statePath)
+			if err != nil {
+				fmt.Printf("ERROR: %s\n", err)
+				os.Exit(1)
+			}
+		}
+		p, err := planManifest(m, getPruneOptions(cmd, m), st)
+		if err != nil {
+			fmt.Printf("ERROR: %s\n", err)
+			os.Exit(1)
+		}
+		printPlan(os.Stdout, p)
+	},
+}
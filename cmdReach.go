@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+func initReachCmd() *cobra.Command {
+	reachCmd.Flags().String("from", "", "Name of source server (required)")
+	reachCmd.Flags().String("to", "", "Name of destination server (required)")
+	reachCmd.Flags().String("port", "", "Port and protocol (example: 5432/tcp, 53/udp) or icmp (required)")
+	reachCmd.Flags().String("format", "text", "Output format: "+strings.Join(reachFormats, ", "))
+	reachCmd.MarkFlagRequired("from")
+	reachCmd.MarkFlagRequired("to")
+	reachCmd.MarkFlagRequired("port")
+	return reachCmd
+}
+
+var reachCmd = &cobra.Command{
+	Use:   "reach",
+	Short: "Check if server can reach other server",
+	Long: "Check if server can reach other server on port, rules which allowed or denied traffic are explained.\n" +
+		"Traffic must be allowed by output rules of source server firewall and input rules of destination server firewall,\n" +
+		"rule with groups matches if peer server is member of any of them.\n" +
+		"Exit code is 0 if traffic is allowed, 2 if it is denied and 1 in case of error.",
+	Run: func(cmd *cobra.Command, args []string) {
+		fromName, _ := cmd.Flags().GetString("from")
+		toName, _ := cmd.Flags().GetString("to")
+		port, _ := cmd.Flags().GetString("port")
+		format, _ := cmd.Flags().GetString("format")
+
+		f, err := parseFlow(port)
+		if err != nil {
+			fmt.Printf("ERROR: %s\n", err)
+			os.Exit(1)
+		}
+		t, err := loadTenantSnapshot()
+		if err != nil {
+			fmt.Printf("ERROR: %s\n", err)
+			os.Exit(1)
+		}
+		from, err := t.serverByName(fromName)
+		if err != nil {
+			fmt.Printf("ERROR: %s\n", err)
+			os.Exit(1)
+		}
+		to, err := t.serverByName(toName)
+		if err != nil {
+			fmt.Printf("ERROR: %s\n", err)
+			os.Exit(1)
+		}
+		fromFw, err := t.serverFirewall(from)
+		if err != nil {
+			fmt.Printf("ERROR: %s\n", err)
+			os.Exit(1)
+		}
+		toFw, err := t.serverFirewall(to)
+		if err != nil {
+			fmt.Printf("ERROR: %s\n", err)
+			os.Exit(1)
+		}
+		r := evaluateReach(from, fromFw, to, toFw, f)
+		if err := printReach(os.Stdout, format, r); err != nil {
+			fmt.Printf("ERROR: %s\n", err)
+			os.Exit(1)
+		}
+		if !r.Allowed {
+			os.Exit(exitUnreachable)
+		}
+	},
+}
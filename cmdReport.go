@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var reportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Generate reports about tenant",
+}
+
+func initReportCmd() *cobra.Command {
+	reportMatrixCmd.Flags().String("by", "server", "Matrix rows and columns: server or group")
+	reportMatrixCmd.Flags().String("format", "markdown", "Output format: "+strings.Join(matrixFormats, ", "))
+	reportCmd.AddCommand(reportMatrixCmd)
+	return reportCmd
+}
+
+var reportMatrixCmd = &cobra.Command{
+	Use:   "matrix",
+	Short: "Report allowed traffic between servers or groups",
+	Long: "Report allowed protocols and ports between all servers (or groups), rows are sources and columns are destinations.\n" +
+		"Traffic must be allowed by output rules of source server firewall and input rules of destination server firewall.\n" +
+		"Traffic between groups is allowed if it is allowed from any member server of source group to any member server of destination group.",
+	Run: func(cmd *cobra.Command, args []string) {
+		by, _ := cmd.Flags().GetString("by")
+		format, _ := cmd.Flags().GetString("format")
+
+		t, err := loadTenantSnapshot()
+		if err != nil {
+			fmt.Printf("ERROR: %s\n", err)
+			os.Exit(1)
+		}
+		var m *reachMatrix
+		switch by {
+		case "server":
+			m, err = t.serverMatrix()
+		case "group":
+			var groups []Group
+			groups, err = listGroups()
+			if err == nil {
+				m, err = t.groupMatrix(groups)
+			}
+		default:
+			err = fmt.Errorf("invalid --by value '%s', use server or group", by)
+		}
+		if err != nil {
+			fmt.Printf("ERROR: %s\n", err)
+			os.Exit(1)
+		}
+		if err := printMatrix(os.Stdout, format, m, "from \\ to"); err != nil {
+			fmt.Printf("ERROR: %s\n", err)
+			os.Exit(1)
+		}
+	},
+}
@@ -1,10 +1,9 @@
 package main
 
 import (
-	"encoding/json"
 	"fmt"
 	"os"
-	"strings"
+	"strconv"
 
 	"github.com/spf13/cobra"
 )
@@ -34,6 +33,7 @@ func initServerCmd() *cobra.Command {
 	serverEnsureCmd.Flags().String("osallupdates", "false", "Apply all OS updates [false, true] (optional)")
 	serverEnsureCmd.Flags().String("osrestart", "false", "Enable OS restart after update [false, true] (optional)")
 	serverEnsureCmd.Flags().Int("osupdatehour", 0, "Define update hour in GMT time [0=anytime] (optional)")
+	serverEnsureCmd.Flags().Bool("dry-run", false, "Only show changes, nothing is written")
 	serverEnsureCmd.MarkFlagRequired("name")
 	serverCmd.AddCommand(serverEnsureCmd)
 
@@ -47,9 +47,228 @@ func initServerCmd() *cobra.Command {
 	serverShowCmd.Flags().String("id", "", "Id of the server to show (required)")
 	serverCmd.AddCommand(serverShowCmd)
 
+	// patch commands change only specified fields of existing server
+	addServerPatchFlags(serverSetCmd)
+	serverSetCmd.Flags().String("firewall-id", "", "Firewall ID")
+	serverSetCmd.Flags().String("firewall-name", "", "Firewall name")
+	serverSetCmd.Flags().String("ip", "", "IP address of the server")
+	serverSetCmd.Flags().String("description", "", "Description of the server")
+	serverSetCmd.Flags().String("autoupdate", "", "Enable shieldoo client auto update [false, true]")
+	serverSetCmd.Flags().String("osautoupdate", "", "Enable OS auto update [false, true]")
+	serverSetCmd.Flags().String("ossecurityupdates", "", "Apply security OS updates [false, true]")
+	serverSetCmd.Flags().String("osallupdates", "", "Apply all OS updates [false, true]")
+	serverSetCmd.Flags().String("osrestart", "", "Enable OS restart after update [false, true]")
+	serverSetCmd.Flags().Int("osupdatehour", 0, "Define update hour in GMT time [0=anytime]")
+	serverCmd.AddCommand(serverSetCmd)
+
+	addServerPatchFlags(serverAddListenerCmd)
+	serverAddListenerCmd.Flags().String("listener", "", "Listener (or comma separated listeners) in format ListenerPort;Protocol;ForwardPort;ForwardHost;Description (required)")
+	serverAddListenerCmd.MarkFlagRequired("listener")
+	serverCmd.AddCommand(serverAddListenerCmd)
+
+	addServerPatchFlags(serverRemoveListenerCmd)
+	serverRemoveListenerCmd.Flags().Int("port", 0, "Listen port of removed listener (required)")
+	serverRemoveListenerCmd.Flags().String("protocol", "", "Protocol of removed listener (tcp or udp), listeners of all protocols are removed if empty")
+	serverRemoveListenerCmd.MarkFlagRequired("port")
+	serverCmd.AddCommand(serverRemoveListenerCmd)
+
+	addServerPatchFlags(serverAddGroupCmd)
+	serverAddGroupCmd.Flags().String("group", "", "Group (or comma separated groups) in format id=###, name=### or objectId=### (required)")
+	serverAddGroupCmd.MarkFlagRequired("group")
+	serverCmd.AddCommand(serverAddGroupCmd)
+
+	addServerPatchFlags(serverRemoveGroupCmd)
+	serverRemoveGroupCmd.Flags().String("group", "", "Group (or comma separated groups) in format id=###, name=### or objectId=### (required)")
+	serverRemoveGroupCmd.MarkFlagRequired("group")
+	serverCmd.AddCommand(serverRemoveGroupCmd)
+
 	return serverCmd
 }
 
+func addServerPatchFlags(cmd *cobra.Command) {
+	cmd.Flags().String("name", "", "Name of the server (name or id is required)")
+	cmd.Flags().String("id", "", "ID of the server (name or id is required)")
+	cmd.Flags().Bool("dry-run", false, "Only show changes, nothing is written")
+}
+
+// runServerPatch reads server selected by --name or --id, modifies it by patch and writes it back,
+// other fields of server are kept
+func runServerPatch(cmd *cobra.Command, patch func(server *Server) error) {
+	name, _ := cmd.Flags().GetString("name")
+	id, _ := cmd.Flags().GetString("id")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	if name == "" && id == "" {
+		fmt.Printf("ERROR: %s\n", "either name or id must be specified")
+		os.Exit(1)
+	}
+	current, err := getServer(name, id)
+	if err != nil {
+		fmt.Printf("ERROR: %s\n", err.Error())
+		os.Exit(1)
+	}
+	if current == nil {
+		fmt.Printf("Server not found\n")
+		os.Exit(1)
+	}
+	server := patchedServer(*current)
+	if err := patch(&server); err != nil {
+		fmt.Printf("ERROR: %s\n", err.Error())
+		os.Exit(1)
+	}
+	if dryRun {
+		rc, err := planServerChange(server, current, nil)
+		if err != nil {
+			fmt.Printf("ERROR: %s\n", err.Error())
+			os.Exit(1)
+		}
+		printPlan(os.Stdout, &plan{Resources: []resourceChange{rc}})
+		return
+	}
+	ret, _, err := saveServer(server, current)
+	if err != nil {
+		fmt.Printf("ERROR: %s\n", err.Error())
+		os.Exit(1)
+	}
+	if err := printOutput(os.Stdout, *ret); err != nil {
+		fmt.Printf("ERROR: %s\n", err.Error())
+		os.Exit(1)
+	}
+}
+
+var serverSetCmd = &cobra.Command{
+	Use:   "set",
+	Short: "Change fields of existing server",
+	Long: "Change only specified fields of existing server, listeners, groups and other fields are kept.\n" +
+		"Unlike ensure command missing flags do not reset fields to default values.",
+	Run: func(cmd *cobra.Command, args []string) {
+		flags := cmd.Flags()
+		if flags.Changed("firewall-id") && flags.Changed("firewall-name") {
+			fmt.Printf("ERROR: %s\n", "only one of firewall-id or firewall-name can be specified")
+			os.Exit(1)
+		}
+		if countChangedFlags(cmd, serverSetFields...) == 0 {
+			fmt.Printf("ERROR: %s\n", "no field to change specified")
+			os.Exit(1)
+		}
+		runServerPatch(cmd, func(server *Server) error {
+			boolFlags := map[string]*bool{
+				"autoupdate":        &server.Autoupdate,
+				"osautoupdate":      &server.OSUpdatePolicy.Enabled,
+				"ossecurityupdates": &server.OSUpdatePolicy.SecurityAutoupdateEnabled,
+				"osallupdates":      &server.OSUpdatePolicy.AllAutoupdateEnabled,
+				"osrestart":         &server.OSUpdatePolicy.RestartAfterUpdate,
+			}
+			for flag, field := range boolFlags {
+				if !flags.Changed(flag) {
+					continue
+				}
+				value, _ := flags.GetString(flag)
+				b, err := strconv.ParseBool(value)
+				if err != nil {
+					return fmt.Errorf("invalid value of %s: %s, expected true or false", flag, value)
+				}
+				*field = b
+			}
+			if flags.Changed("firewall-id") {
+				id, _ := flags.GetString("firewall-id")
+				server.Firewall = Firewall{Id: id}
+			}
+			if flags.Changed("firewall-name") {
+				name, _ := flags.GetString("firewall-name")
+				server.Firewall = Firewall{Name: name}
+			}
+			if flags.Changed("ip") {
+				server.IpAddress, _ = flags.GetString("ip")
+			}
+			if flags.Changed("description") {
+				server.Description, _ = flags.GetString("description")
+			}
+			if flags.Changed("osupdatehour") {
+				server.OSUpdatePolicy.UpdateHour, _ = flags.GetInt("osupdatehour")
+				if err := validateUpdateHour(server.OSUpdatePolicy.UpdateHour); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	},
+}
+
+// fields of server which can be changed by set command
+var serverSetFields = []string{"firewall-id", "firewall-name", "ip", "description", "autoupdate",
+	"osautoupdate", "ossecurityupdates", "osallupdates", "osrestart", "osupdatehour"}
+
+// countChangedFlags returns number of flags from names which were set on command line
+func countChangedFlags(cmd *cobra.Command, names ...string) int {
+	ret := 0
+	for _, name := range names {
+		if cmd.Flags().Changed(name) {
+			ret++
+		}
+	}
+	return ret
+}
+
+var serverAddListenerCmd = &cobra.Command{
+	Use:   "add-listener",
+	Short: "Add listener to existing server",
+	Run: func(cmd *cobra.Command, args []string) {
+		listener, _ := cmd.Flags().GetString("listener")
+		listeners, err := parseListeners(listener)
+		if err != nil {
+			fmt.Printf("ERROR: %s\n", err.Error())
+			os.Exit(1)
+		}
+		runServerPatch(cmd, func(server *Server) error {
+			return addServerListeners(server, listeners)
+		})
+	},
+}
+
+var serverRemoveListenerCmd = &cobra.Command{
+	Use:   "remove-listener",
+	Short: "Remove listener from existing server",
+	Run: func(cmd *cobra.Command, args []string) {
+		port, _ := cmd.Flags().GetInt("port")
+		protocol, _ := cmd.Flags().GetString("protocol")
+		runServerPatch(cmd, func(server *Server) error {
+			return removeServerListeners(server, port, protocol)
+		})
+	},
+}
+
+var serverAddGroupCmd = &cobra.Command{
+	Use:   "add-group",
+	Short: "Add existing server to group",
+	Run: func(cmd *cobra.Command, args []string) {
+		group, _ := cmd.Flags().GetString("group")
+		groups, err := parseGroups(group)
+		if err != nil {
+			fmt.Printf("ERROR: %s\n", err.Error())
+			os.Exit(1)
+		}
+		runServerPatch(cmd, func(server *Server) error {
+			return addServerGroups(server, groups)
+		})
+	},
+}
+
+var serverRemoveGroupCmd = &cobra.Command{
+	Use:   "remove-group",
+	Short: "Remove existing server from group",
+	Run: func(cmd *cobra.Command, args []string) {
+		group, _ := cmd.Flags().GetString("group")
+		groups, err := parseGroups(group)
+		if err != nil {
+			fmt.Printf("ERROR: %s\n", err.Error())
+			os.Exit(1)
+		}
+		runServerPatch(cmd, func(server *Server) error {
+			return removeServerGroups(server, groups)
+		})
+	},
+}
+
 var serverEnsureCmd = &cobra.Command{
 	Use:   "ensure",
 	Short: "Ensure a server (create or update)",
@@ -67,60 +286,23 @@ var serverEnsureCmd = &cobra.Command{
 		osallupdates, _ := cmd.Flags().GetString("osallupdates")
 		osrestart, _ := cmd.Flags().GetString("osrestart")
 		osupdatehour, _ := cmd.Flags().GetInt("osupdatehour")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
 
 		if firewallId == "" && firewallName == "" {
-			fmt.Printf("Error: either firewall-id or firewall-id must be specified\n")
+			fmt.Printf("Error: either firewall-id or firewall-name must be specified\n")
 			os.Exit(1)
 		}
-
-		// get firewall id if name is given
-		if firewallId == "" {
-			ret, err := callApi("GET", "firewalls", firewallName, "", nil)
-			if err != nil {
-				fmt.Printf("ERROR: %s\n", err.Error(), ret)
-				os.Exit(1)
-			}
-			var fws []Firewall
-			err = json.Unmarshal([]byte(ret), &fws)
-			if err != nil {
-				fmt.Printf("ERROR: %s\n", err.Error())
-				os.Exit(1)
-			}
-			if len(fws) == 0 {
-				fmt.Printf("ERROR: no firewall found with name '%s'\n", firewallName)
-				os.Exit(1)
-			}
-			firewallId = fws[0].Id
-		}
-
-		// parse groups
-		groupsList := strings.Split(groups, ",")
-		var serverGroups []Group
-		for _, group := range groupsList {
-			group = strings.TrimSpace(group)
-			if group == "" {
-				continue
-			}
-			g, err := parseGroup(group)
-			if err != nil {
-				fmt.Printf("ERROR: %s\n", err.Error())
-				os.Exit(1)
-			}
-			serverGroups = append(serverGroups, g)
-		}
-
-		// convert server name to id
-		ret, err := callApi("GET", "servers", name, "", nil)
-		if err != nil {
-			fmt.Printf("ERROR: %s %s\n", err.Error(), ret)
+		if err := validateUpdateHour(osupdatehour); err != nil {
+			fmt.Printf("ERROR: %s\n", err.Error())
 			os.Exit(1)
 		}
-		var servers []Server
-		err = json.Unmarshal([]byte(ret), &servers)
+
+		serverGroups, err := parseGroups(groups)
 		if err != nil {
-			fmt.Printf("ERROR: %s %s\n", err.Error(), ret)
+			fmt.Printf("ERROR: %s\n", err.Error())
 			os.Exit(1)
 		}
+
 		list, err := parseListeners(listeners)
 		if err != nil {
 			fmt.Printf("ERROR: %s\n", err.Error())
@@ -130,7 +312,8 @@ var serverEnsureCmd = &cobra.Command{
 			Name:   name,
 			Groups: serverGroups,
 			Firewall: Firewall{
-				Id: firewallId,
+				Id:   firewallId,
+				Name: firewallName,
 			},
 			Listeners:   list,
 			IpAddress:   ipAddr,
@@ -144,23 +327,24 @@ var serverEnsureCmd = &cobra.Command{
 				UpdateHour:                osupdatehour,
 			},
 		}
-		if len(servers) > 0 {
-			// server already exists
-			server.Id = servers[0].Id
-			ret, err = callApi("PUT", "servers", "", server.Id, server)
+		if dryRun {
+			rc, err := planServer(server, nil)
 			if err != nil {
-				fmt.Printf("ERROR: %s %s\n", err.Error(), ret)
-				os.Exit(1)
-			}
-		} else {
-			// create server
-			ret, err = callApi("POST", "servers", "", "", server)
-			if err != nil {
-				fmt.Printf("ERROR: %s %s\n", err.Error(), ret)
+				fmt.Printf("ERROR: %s\n", err.Error())
 				os.Exit(1)
 			}
+			printPlan(os.Stdout, &plan{Resources: []resourceChange{rc}})
+			return
+		}
+		ret, _, err := ensureServer(server)
+		if err != nil {
+			fmt.Printf("ERROR: %s\n", err.Error())
+			os.Exit(1)
+		}
+		if err := printOutput(os.Stdout, *ret); err != nil {
+			fmt.Printf("ERROR: %s\n", err.Error())
+			os.Exit(1)
 		}
-		fmt.Println(ret)
 	},
 }
 
@@ -169,9 +353,8 @@ var serverDeleteCmd = &cobra.Command{
 	Short: "Delete a server",
 	Run: func(cmd *cobra.Command, args []string) {
 		id, _ := cmd.Flags().GetString("id")
-		ret, err := callApi("DELETE", "servers", "", id, nil)
-		if err != nil {
-			fmt.Printf("ERROR: %s %s\n", err.Error(), ret)
+		if err := deleteServer(id); err != nil {
+			fmt.Printf("ERROR: %s\n", err.Error())
 			os.Exit(1)
 		}
 		fmt.Println("Server deleted")
@@ -182,12 +365,15 @@ var serverListCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List all servers",
 	Run: func(cmd *cobra.Command, args []string) {
-		ret, err := callApi("GET", "servers", "", "", nil)
+		ret, err := listServers()
 		if err != nil {
-			fmt.Printf("ERROR: ", err.Error())
+			fmt.Printf("ERROR: %s\n", err.Error())
+			os.Exit(1)
+		}
+		if err := printOutput(os.Stdout, ret); err != nil {
+			fmt.Printf("ERROR: %s\n", err.Error())
 			os.Exit(1)
 		}
-		fmt.Println(ret)
 	},
 }
 
@@ -201,20 +387,18 @@ var serverShowCmd = &cobra.Command{
 			fmt.Printf("Error: either name or id must be specified\n")
 			os.Exit(1)
 		}
-		ret, err := callApi("GET", "servers", name, id, nil)
+		ret, err := getServer(name, id)
 		if err != nil {
-			fmt.Printf("ERROR: %s - %s\n", err.Error(), ret)
+			fmt.Printf("ERROR: %s\n", err.Error())
 			os.Exit(1)
 		}
-		if ret == "" || strings.TrimSpace(ret) == "null" {
+		if ret == nil {
 			fmt.Printf("Server not found\n")
 			os.Exit(1)
 		}
-		// if it is array, clean it up
-		if ret[0] == '[' {
-			ret = strings.TrimPrefix(ret, "[")
-			ret = strings.TrimSuffix(ret, "]")
+		if err := printOutput(os.Stdout, *ret); err != nil {
+			fmt.Printf("ERROR: %s\n", err.Error())
+			os.Exit(1)
 		}
-		fmt.Println(ret)
 	},
 }
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var stateCmd = &cobra.Command{
+	Use:   "state",
+	Short: "Manage state file of manifests",
+}
+
+func initStateCmd() *cobra.Command {
+	stateCmd.PersistentFlags().String("state", "", "State location: local path, http(s)://... URL or s3://bucket/key (required)")
+	stateCmd.MarkPersistentFlagRequired("state")
+	stateCmd.AddCommand(stateShowCmd)
+	stateCmd.AddCommand(stateForceUnlockCmd)
+	return stateCmd
+}
+
+var stateShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Show IDs of firewalls and servers tracked in state",
+	Run: func(cmd *cobra.Command, args []string) {
+		location, _ := cmd.Flags().GetString("state")
+
+		st, err := loadState(location)
+		if err != nil {
+			fmt.Printf("ERROR: %s\n", err)
+			os.Exit(1)
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(st); err != nil {
+			fmt.Printf("ERROR: %s\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var stateForceUnlockCmd = &cobra.Command{
+	Use:   "force-unlock LOCK_ID",
+	Short: "Release stale lock of state",
+	Long: "Release stale lock of state left by process which was killed.\n" +
+		"Lock ID is part of error message of command which could not lock state.\n" +
+		"Local state lock is released automatically when process holding it exits (except Windows).",
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		location, _ := cmd.Flags().GetString("state")
+
+		b, err := newStateBackend(location)
+		if err != nil {
+			fmt.Printf("ERROR: %s\n", err)
+			os.Exit(1)
+		}
+		if err := b.unlock(args[0]); err != nil {
+			fmt.Printf("ERROR: %s\n", err)
+			os.Exit(1)
+		}
+		fmt.Println("State unlocked")
+	},
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// configContext is named connection to shieldoo tenant
+type configContext struct {
+	Name   string `yaml:"name"`
+	Uri    string `yaml:"uri"`
+	ApiKey string `yaml:"apiKey,omitempty"`
+	// credential store (keyring or file) where API key is stored by login command
+	CredentialStore string `yaml:"credentialStore,omitempty"`
+	// external command which prints API key to standard output
+	CredentialProcess string `yaml:"credentialProcess,omitempty"`
+}
+
+// cliConfig is content of config file (~/.config/shieldoo/config.yaml by default)
+type cliConfig struct {
+	CurrentContext string          `yaml:"currentContext,omitempty"`
+	Contexts       []configContext `yaml:"contexts"`
+}
+
+// profile selected by global --profile flag
+var profileName = ""
+
+// configPath returns path of config file, it can be changed by SHIELDOO_CONFIG environment variable
+func configPath() (string, error) {
+	if p := os.Getenv("SHIELDOO_CONFIG"); p != "" {
+		return p, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "shieldoo", "config.yaml"), nil
+}
+
+// loadConfig loads config file, empty config is returned if file does not exist
+func loadConfig() (*cliConfig, error) {
+	cfg := &cliConfig{}
+	p, err := configPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("%s: %s", p, err)
+	}
+	return cfg, nil
+}
+
+// saveConfig writes config file, file is readable only by owner because it can contain API keys
+func saveConfig(cfg *cliConfig) error {
+	p, err := configPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0700); err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(cfg); err != nil {
+		return err
+	}
+	return os.WriteFile(p, buf.Bytes(), 0600)
+}
+
+func (c *cliConfig) findContext(name string) *configContext {
+	for i := range c.Contexts {
+		if c.Contexts[i].Name == name {
+			return &c.Contexts[i]
+		}
+	}
+	return nil
+}
+
+// selectedProfile returns name of profile selected by --profile flag, environment or current context
+func selectedProfile(cfg *cliConfig) string {
+	if profileName != "" {
+		return profileName
+	}
+	if p := os.Getenv("SHIELDOO_PROFILE"); p != "" {
+		return p
+	}
+	return cfg.CurrentContext
+}
+
+// loadConnection sets shieldoo URI and API key from selected profile,
+// SHIELDOO_URI and SHIELDOO_APIKEY environment variables override values from profile
+func loadConnection() error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	name := selectedProfile(cfg)
+	if name != "" {
+		ctx := cfg.findContext(name)
+		if ctx == nil {
+			return fmt.Errorf("profile '%s' not found in config", name)
+		}
+		shieldooUri = ctx.Uri
+		// API key from environment is used without asking credential store
+		if os.Getenv("SHIELDOO_APIKEY") == "" {
+			shieldooApiKey, err = contextApiKey(ctx)
+			if err != nil {
+				return fmt.Errorf("profile '%s': %s", name, err)
+			}
+		}
+	}
+	if uri := os.Getenv("SHIELDOO_URI"); uri != "" {
+		shieldooUri = uri
+	}
+	if apiKey := os.Getenv("SHIELDOO_APIKEY"); apiKey != "" {
+		shieldooApiKey = apiKey
+	}
+	if shieldooUri == "" {
+		return errors.New("shieldoo URI is not set, use SHIELDOO_URI environment variable or profile (see shieldoo config set-context)")
+	}
+	if shieldooApiKey == "" {
+		return errors.New("shieldoo API key is not set, use SHIELDOO_APIKEY environment variable or profile (see shieldoo login)")
+	}
+	return nil
+}
@@ -0,0 +1,312 @@
+package main
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/term"
+)
+
+// credentialStore stores API keys of profiles outside of config file
+type credentialStore interface {
+	Get(profile string) (string, error)
+	Set(profile string, apiKey string) error
+	Delete(profile string) error
+}
+
+const (
+	credentialStoreKeyring = "keyring"
+	credentialStoreFile    = "file"
+	// service name used in OS keyring
+	keyringService = "shieldoo-cli"
+)
+
+func newCredentialStore(kind string) (credentialStore, error) {
+	switch kind {
+	case credentialStoreKeyring:
+		return keyringStore{}, nil
+	case credentialStoreFile:
+		p, err := configPath()
+		if err != nil {
+			return nil, err
+		}
+		return &encryptedFileStore{path: filepath.Join(filepath.Dir(p), "credentials.enc")}, nil
+	}
+	return nil, fmt.Errorf("invalid credential store: %s (expected %s or %s)", kind, credentialStoreKeyring, credentialStoreFile)
+}
+
+// defaultCredentialStore returns keyring if it is available on this system, encrypted file otherwise
+func defaultCredentialStore() string {
+	if keyringAvailable() {
+		return credentialStoreKeyring
+	}
+	return credentialStoreFile
+}
+
+// keyringStore uses Secret Service (through secret-tool) on Linux and Keychain on macOS
+type keyringStore struct{}
+
+// keyringOS selects keyring tool, tests use it to check commands of other systems
+var keyringOS = runtime.GOOS
+
+// runKeyringTool runs keyring tool with stdin and returns its output, it is replaced in tests
+var runKeyringTool = execKeyringTool
+
+func keyringAvailable() bool {
+	tool := "secret-tool"
+	if keyringOS == "darwin" {
+		tool = "security"
+	}
+	_, err := exec.LookPath(tool)
+	return err == nil
+}
+
+func execKeyringTool(stdin string, name string, args ...string) (string, error) {
+	cmd := exec.Command(name, args...)
+	cmd.Stdin = strings.NewReader(stdin)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%s: %s", name, msg)
+		}
+		return "", fmt.Errorf("%s: %s", name, err)
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+func (keyringStore) Get(profile string) (string, error) {
+	var key string
+	var err error
+	if keyringOS == "darwin" {
+		key, err = runKeyringTool("", "security", "find-generic-password", "-s", keyringService, "-a", profile, "-w")
+	} else {
+		key, err = runKeyringTool("", "secret-tool", "lookup", "service", keyringService, "profile", profile)
+	}
+	if err == nil && key == "" {
+		err = fmt.Errorf("API key for profile '%s' not found in keyring", profile)
+	}
+	return key, err
+}
+
+func (s keyringStore) Set(profile string, apiKey string) error {
+	if keyringOS != "darwin" {
+		_, err := runKeyringTool(apiKey, "secret-tool", "store", "--label=shieldoo "+profile, "service", keyringService, "profile", profile)
+		return err
+	}
+	// interactive mode of security reads command from stdin, so API key is not visible in process
+	// arguments (-w without value prompts on terminal instead of reading stdin)
+	if strings.ContainsAny(profile+apiKey, "\"\\\n") {
+		return errors.New("profile name and API key stored in keychain can not contain quotes, backslashes or new lines")
+	}
+	command := fmt.Sprintf("add-generic-password -U -s %s -a \"%s\" -w \"%s\"\n", keyringService, profile, apiKey)
+	if _, err := runKeyringTool(command, "security", "-i"); err != nil {
+		return err
+	}
+	// exit code of interactive mode does not report failure of command
+	stored, err := s.Get(profile)
+	if err != nil {
+		return err
+	}
+	if stored != apiKey {
+		return errors.New("API key was not stored in keychain")
+	}
+	return nil
+}
+
+func (keyringStore) Delete(profile string) error {
+	var err error
+	if keyringOS == "darwin" {
+		_, err = runKeyringTool("", "security", "delete-generic-password", "-s", keyringService, "-a", profile)
+	} else {
+		_, err = runKeyringTool("", "secret-tool", "clear", "service", keyringService, "profile", profile)
+	}
+	return err
+}
+
+// encryptedFileStore keeps API keys of all profiles in one file encrypted by AES-GCM,
+// key is derived from passphrase (SHIELDOO_PASSPHRASE environment variable or prompt) by scrypt
+type encryptedFileStore struct {
+	path       string
+	passphrase string
+}
+
+type encryptedFile struct {
+	Salt  []byte `json:"salt"`
+	Nonce []byte `json:"nonce"`
+	Data  []byte `json:"data"`
+}
+
+func (s *encryptedFileStore) getPassphrase() (string, error) {
+	if s.passphrase != "" {
+		return s.passphrase, nil
+	}
+	s.passphrase = os.Getenv("SHIELDOO_PASSPHRASE")
+	if s.passphrase != "" {
+		return s.passphrase, nil
+	}
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return "", errors.New("passphrase of credentials file is required, use SHIELDOO_PASSPHRASE environment variable")
+	}
+	fmt.Fprint(os.Stderr, "Passphrase of credentials file: ")
+	data, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	s.passphrase = string(data)
+	if s.passphrase == "" {
+		return "", errors.New("empty passphrase")
+	}
+	return s.passphrase, nil
+}
+
+func (s *encryptedFileStore) cipher(salt []byte) (cipher.AEAD, error) {
+	passphrase, err := s.getPassphrase()
+	if err != nil {
+		return nil, err
+	}
+	key, err := scrypt.Key([]byte(passphrase), salt, 1<<15, 8, 1, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func (s *encryptedFileStore) load() (map[string]string, error) {
+	keys := map[string]string{}
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return keys, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var f encryptedFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("%s: %s", s.path, err)
+	}
+	aead, err := s.cipher(f.Salt)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := aead.Open(nil, f.Nonce, f.Data, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: invalid passphrase or corrupted file", s.path)
+	}
+	if err := json.Unmarshal(plain, &keys); err != nil {
+		return nil, fmt.Errorf("%s: %s", s.path, err)
+	}
+	return keys, nil
+}
+
+func (s *encryptedFileStore) save(keys map[string]string) error {
+	plain, err := json.Marshal(keys)
+	if err != nil {
+		return err
+	}
+	f := encryptedFile{Salt: make([]byte, 16)}
+	if _, err := rand.Read(f.Salt); err != nil {
+		return err
+	}
+	aead, err := s.cipher(f.Salt)
+	if err != nil {
+		return err
+	}
+	f.Nonce = make([]byte, aead.NonceSize())
+	if _, err := rand.Read(f.Nonce); err != nil {
+		return err
+	}
+	f.Data = aead.Seal(nil, f.Nonce, plain, nil)
+	data, err := json.Marshal(f)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(s.path, data, 0600)
+}
+
+func (s *encryptedFileStore) Get(profile string) (string, error) {
+	keys, err := s.load()
+	if err != nil {
+		return "", err
+	}
+	key, ok := keys[profile]
+	if !ok {
+		return "", fmt.Errorf("API key for profile '%s' not found in %s", profile, s.path)
+	}
+	return key, nil
+}
+
+func (s *encryptedFileStore) Set(profile string, apiKey string) error {
+	keys, err := s.load()
+	if err != nil {
+		return err
+	}
+	keys[profile] = apiKey
+	return s.save(keys)
+}
+
+func (s *encryptedFileStore) Delete(profile string) error {
+	keys, err := s.load()
+	if err != nil {
+		return err
+	}
+	delete(keys, profile)
+	return s.save(keys)
+}
+
+// runCredentialProcess runs external command and returns its output as API key
+func runCredentialProcess(command string) (string, error) {
+	shell, flag := "sh", "-c"
+	if runtime.GOOS == "windows" {
+		shell, flag = "cmd", "/C"
+	}
+	cmd := exec.Command(shell, flag, command)
+	cmd.Stdin = os.Stdin
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("credential process failed: %s", err)
+	}
+	key := strings.TrimSpace(string(out))
+	if key == "" {
+		return "", errors.New("credential process returned empty API key")
+	}
+	return key, nil
+}
+
+// contextApiKey returns API key of context from config, credential process or credential store
+func contextApiKey(ctx *configContext) (string, error) {
+	switch {
+	case ctx.ApiKey != "":
+		return ctx.ApiKey, nil
+	case ctx.CredentialProcess != "":
+		return runCredentialProcess(ctx.CredentialProcess)
+	case ctx.CredentialStore != "":
+		store, err := newCredentialStore(ctx.CredentialStore)
+		if err != nil {
+			return "", err
+		}
+		return store.Get(ctx.Name)
+	}
+	return "", nil
+}
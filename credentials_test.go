@@ -0,0 +1,225 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestEncryptedFileStore(t *testing.T) {
+	t.Setenv("SHIELDOO_PASSPHRASE", "correct horse")
+	path := filepath.Join(t.TempDir(), "credentials.enc")
+	store := &encryptedFileStore{path: path}
+	if err := store.Set("dev", "dev-key"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Set("prod", "prod-key"); err != nil {
+		t.Fatal(err)
+	}
+
+	// new store reads keys written by other one
+	reader := &encryptedFileStore{path: path}
+	for profile, want := range map[string]string{"dev": "dev-key", "prod": "prod-key"} {
+		if got, err := reader.Get(profile); err != nil || got != want {
+			t.Errorf("Get(%s) = %q, %v, want %q", profile, got, err, want)
+		}
+	}
+	if _, err := reader.Get("test"); err == nil {
+		t.Error("expected error for unknown profile")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "dev-key") {
+		t.Error("API key is stored in plain text")
+	}
+	if fi, _ := os.Stat(path); fi.Mode().Perm() != 0600 {
+		t.Errorf("file mode %s, want 0600", fi.Mode().Perm())
+	}
+
+	t.Setenv("SHIELDOO_PASSPHRASE", "wrong")
+	if _, err := (&encryptedFileStore{path: path}).Get("dev"); err == nil || !strings.Contains(err.Error(), "invalid passphrase") {
+		t.Errorf("got error %v, want invalid passphrase", err)
+	}
+	// file is not changed by failed write
+	if err := (&encryptedFileStore{path: path}).Set("dev", "other"); err == nil {
+		t.Error("key was written with wrong passphrase")
+	}
+	if after, _ := os.ReadFile(path); string(after) != string(data) {
+		t.Error("file was changed by write with wrong passphrase")
+	}
+
+	// tests do not run in terminal, so passphrase can not be prompted
+	t.Setenv("SHIELDOO_PASSPHRASE", "")
+	if _, err := (&encryptedFileStore{path: path}).Get("dev"); err == nil || !strings.Contains(err.Error(), "SHIELDOO_PASSPHRASE") {
+		t.Errorf("got error %v, want error about missing passphrase", err)
+	}
+
+	t.Setenv("SHIELDOO_PASSPHRASE", "correct horse")
+	if err := reader.Delete("dev"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := (&encryptedFileStore{path: path}).Get("dev"); err == nil {
+		t.Error("deleted key was found")
+	}
+}
+
+type keyringCall struct {
+	stdin string
+	args  []string
+}
+
+// fakeKeyring replaces keyring tool, keychain of macOS is emulated by stored command
+func fakeKeyring(t *testing.T, goos string) *[]keyringCall {
+	var calls []keyringCall
+	stored := ""
+	goos0, run0 := keyringOS, runKeyringTool
+	t.Cleanup(func() { keyringOS, runKeyringTool = goos0, run0 })
+	keyringOS = goos
+	runKeyringTool = func(stdin string, name string, args ...string) (string, error) {
+		calls = append(calls, keyringCall{stdin: stdin, args: append([]string{name}, args...)})
+		switch {
+		case name == "security" && args[0] == "-i":
+			if m := regexp.MustCompile(`-w "(.*)"`).FindStringSubmatch(stdin); m != nil {
+				stored = m[1]
+			}
+		case name == "security" && args[0] == "find-generic-password":
+			return stored, nil
+		}
+		return "", nil
+	}
+	return &calls
+}
+
+func TestKeyringStoreCommands(t *testing.T) {
+	const apiKey = "secret-api-key"
+	t.Run("darwin", func(t *testing.T) {
+		calls := fakeKeyring(t, "darwin")
+		if err := (keyringStore{}).Set("prod", apiKey); err != nil {
+			t.Fatal(err)
+		}
+		if len(*calls) != 2 {
+			t.Fatalf("got %d calls, want add and read back: %+v", len(*calls), *calls)
+		}
+		add := (*calls)[0]
+		if strings.Join(add.args, " ") != "security -i" {
+			t.Errorf("got command %v, want security -i", add.args)
+		}
+		if want := "add-generic-password -U -s shieldoo-cli -a \"prod\" -w \"" + apiKey + "\"\n"; add.stdin != want {
+			t.Errorf("got stdin %q, want %q", add.stdin, want)
+		}
+		for _, c := range *calls {
+			if strings.Contains(strings.Join(c.args, " "), apiKey) {
+				t.Errorf("API key is visible in arguments: %v", c.args)
+			}
+		}
+	})
+	t.Run("darwin unsafe key", func(t *testing.T) {
+		calls := fakeKeyring(t, "darwin")
+		if err := (keyringStore{}).Set("prod", `key" -w "other`); err == nil {
+			t.Error("key with quotes was accepted")
+		}
+		if len(*calls) != 0 {
+			t.Errorf("keyring tool was called: %+v", *calls)
+		}
+	})
+	t.Run("darwin failed write", func(t *testing.T) {
+		fakeKeyring(t, "darwin")
+		run := runKeyringTool
+		runKeyringTool = func(stdin string, name string, args ...string) (string, error) {
+			if args[0] == "-i" {
+				// failed command in interactive mode does not change exit code
+				return "", nil
+			}
+			return run(stdin, name, args...)
+		}
+		if err := (keyringStore{}).Set("prod", apiKey); err == nil {
+			t.Error("expected error when key is not stored")
+		}
+	})
+	t.Run("linux", func(t *testing.T) {
+		calls := fakeKeyring(t, "linux")
+		if err := (keyringStore{}).Set("prod", apiKey); err != nil {
+			t.Fatal(err)
+		}
+		if len(*calls) != 1 || (*calls)[0].stdin != apiKey || (*calls)[0].args[0] != "secret-tool" {
+			t.Fatalf("unexpected calls %+v", *calls)
+		}
+		if strings.Contains(strings.Join((*calls)[0].args, " "), apiKey) {
+			t.Errorf("API key is visible in arguments: %v", (*calls)[0].args)
+		}
+	})
+}
+
+func TestLoadConnection(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("SHIELDOO_CONFIG", filepath.Join(dir, "config.yaml"))
+	t.Setenv("SHIELDOO_PASSPHRASE", "passphrase")
+	if err := (&encryptedFileStore{path: filepath.Join(dir, "credentials.enc")}).Set("stored", "stored-key"); err != nil {
+		t.Fatal(err)
+	}
+	err := saveConfig(&cliConfig{CurrentContext: "inline", Contexts: []configContext{
+		{Name: "inline", Uri: "https://inline", ApiKey: "inline-key"},
+		{Name: "process", Uri: "https://process", CredentialProcess: "echo process-key"},
+		{Name: "failing", Uri: "https://failing", CredentialProcess: "exit 1"},
+		{Name: "stored", Uri: "https://stored", CredentialStore: credentialStoreFile},
+		// API key in config takes precedence over credential process and store
+		{Name: "both", Uri: "https://both", ApiKey: "both-key", CredentialProcess: "exit 1", CredentialStore: credentialStoreFile},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name      string
+		flag      string
+		env       map[string]string
+		wantUri   string
+		wantKey   string
+		wantError bool
+	}{
+		{name: "current context", wantUri: "https://inline", wantKey: "inline-key"},
+		{name: "profile flag", flag: "process", wantUri: "https://process", wantKey: "process-key"},
+		{name: "profile environment", env: map[string]string{"SHIELDOO_PROFILE": "stored"}, wantUri: "https://stored", wantKey: "stored-key"},
+		{name: "flag wins over environment", flag: "process", env: map[string]string{"SHIELDOO_PROFILE": "stored"}, wantUri: "https://process", wantKey: "process-key"},
+		{name: "config key wins over process and store", flag: "both", wantUri: "https://both", wantKey: "both-key"},
+		{name: "failing process", flag: "failing", wantError: true},
+		{name: "environment key skips process", flag: "failing", env: map[string]string{"SHIELDOO_APIKEY": "env-key"}, wantUri: "https://failing", wantKey: "env-key"},
+		{name: "environment overrides profile", env: map[string]string{"SHIELDOO_URI": "https://env", "SHIELDOO_APIKEY": "env-key"}, wantUri: "https://env", wantKey: "env-key"},
+		{name: "unknown profile", flag: "missing", wantError: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, v := range []string{"SHIELDOO_PROFILE", "SHIELDOO_URI", "SHIELDOO_APIKEY"} {
+				t.Setenv(v, tt.env[v])
+			}
+			profileName, shieldooUri, shieldooApiKey = tt.flag, "", ""
+			defer func() { profileName, shieldooUri, shieldooApiKey = "", "", "" }()
+			err := loadConnection()
+			if tt.wantError {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if shieldooUri != tt.wantUri || shieldooApiKey != tt.wantKey {
+				t.Errorf("got %s %s, want %s %s", shieldooUri, shieldooApiKey, tt.wantUri, tt.wantKey)
+			}
+		})
+	}
+}
+
+func TestCredentialProcess(t *testing.T) {
+	if key, err := runCredentialProcess("echo '  key-from-process  '"); err != nil || key != "key-from-process" {
+		t.Errorf("got %q, %v, want key-from-process", key, err)
+	}
+	if _, err := runCredentialProcess("true"); err == nil || !strings.Contains(err.Error(), "empty API key") {
+		t.Errorf("got error %v, want empty API key", err)
+	}
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// exit code of drift command if tenant differs from manifest (1 is used for errors)
+const exitDrift = 2
+
+const (
+	driftInSync    = "in-sync"
+	driftMissing   = "missing"
+	driftChanged   = "changed"
+	driftUnmanaged = "unmanaged"
+	// resource is tracked in state file, but it was deleted outside of manifest
+	driftDeleted = "deleted"
+)
+
+// drift status of resource by plan action
+var driftStatuses = map[string]string{
+	actionNone:   driftInSync,
+	actionCreate: driftMissing,
+	actionUpdate: driftChanged,
+	actionDelete: driftUnmanaged,
+}
+
+var driftFormats = []string{"text", "json", "junit"}
+
+func isDriftFormat(format string) bool {
+	for _, f := range driftFormats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+type driftChange struct {
+	Op    string `json:"op"`
+	Field string `json:"field"`
+	Live  string `json:"live,omitempty"`
+	Want  string `json:"declared,omitempty"`
+}
+
+type driftResource struct {
+	Kind    string        `json:"kind"`
+	Name    string        `json:"name"`
+	Id      string        `json:"id,omitempty"`
+	Status  string        `json:"status"`
+	Changes []driftChange `json:"changes,omitempty"`
+}
+
+// driftReport is result of comparison of tenant with manifest
+type driftReport struct {
+	InSync    bool            `json:"inSync"`
+	Missing   int             `json:"missing"`
+	Changed   int             `json:"changed"`
+	Unmanaged int             `json:"unmanaged"`
+	Deleted   int             `json:"deleted,omitempty"`
+	Resources []driftResource `json:"resources"`
+}
+
+// newDriftReport converts plan to drift report, create action means that resource is missing in tenant,
+// delete action that resource is not managed by manifest
+func newDriftReport(p *plan) *driftReport {
+	r := &driftReport{
+		InSync:    !p.hasChanges(),
+		Missing:   p.count(actionCreate),
+		Changed:   p.count(actionUpdate),
+		Unmanaged: p.count(actionDelete),
+		Resources: []driftResource{},
+	}
+	for _, rc := range p.Resources {
+		dr := driftResource{Kind: rc.Kind, Name: rc.Name, Id: rc.Id, Status: driftStatuses[rc.Action]}
+		if rc.Lost {
+			dr.Status = driftDeleted
+			r.Missing--
+			r.Deleted++
+		}
+		for _, c := range rc.Changes {
+			dr.Changes = append(dr.Changes, driftChange{Op: c.Op, Field: c.Field, Live: c.Old, Want: c.New})
+		}
+		r.Resources = append(r.Resources, dr)
+	}
+	return r
+}
+
+func (r *driftReport) summary() string {
+	if r.InSync {
+		return "No drift. Tenant matches manifest."
+	}
+	if r.Deleted > 0 {
+		return fmt.Sprintf("Drift: %d missing, %d deleted, %d changed, %d unmanaged.", r.Missing, r.Deleted, r.Changed, r.Unmanaged)
+	}
+	return fmt.Sprintf("Drift: %d missing, %d changed, %d unmanaged.", r.Missing, r.Changed, r.Unmanaged)
+}
+
+func printDrift(w io.Writer, format string, p *plan) error {
+	r := newDriftReport(p)
+	switch format {
+	case "text":
+		for _, rc := range p.Resources {
+			if rc.Action == actionNone {
+				continue
+			}
+			status := driftStatuses[rc.Action]
+			if rc.Lost {
+				status = driftDeleted
+			}
+			fmt.Fprintf(w, "  %s %s %q %s\n", planActionSymbols[rc.Action], rc.Kind, rc.Name, status)
+			printFieldChanges(w, rc.Changes)
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintln(w, r.summary())
+		return nil
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(r)
+	case "junit":
+		return writeDriftJUnit(w, r)
+	}
+	return fmt.Errorf("unknown format '%s', supported formats are %s", format, strings.Join(driftFormats, ", "))
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+type junitTestCase struct {
+	ClassName string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitTestSuite struct {
+	XMLName   xml.Name        `xml:"testsuite"`
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+// writeDriftJUnit writes report as JUnit XML, every resource is one test case
+// and drifted resources are failures
+func writeDriftJUnit(w io.Writer, r *driftReport) error {
+	suite := junitTestSuite{Name: "shieldoo drift", Tests: len(r.Resources)}
+	for _, dr := range r.Resources {
+		tc := junitTestCase{ClassName: dr.Kind, Name: dr.Name}
+		if dr.Status != driftInSync {
+			var sb strings.Builder
+			for _, c := range dr.Changes {
+				switch c.Op {
+				case "+":
+					fmt.Fprintf(&sb, "+ %s: %s\n", c.Field, c.Want)
+				case "-":
+					fmt.Fprintf(&sb, "- %s: %s\n", c.Field, c.Live)
+				default:
+					fmt.Fprintf(&sb, "~ %s: %s -> %s\n", c.Field, planValue(c.Live), planValue(c.Want))
+				}
+			}
+			tc.Failure = &junitFailure{
+				Message: fmt.Sprintf("%s %s is %s", dr.Kind, dr.Name, dr.Status),
+				Type:    dr.Status,
+				Text:    sb.String(),
+			}
+			suite.Failures++
+		}
+		suite.TestCases = append(suite.TestCases, tc)
+	}
+	fmt.Fprint(w, xml.Header)
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(suite); err != nil {
+		return err
+	}
+	fmt.Fprintln(w)
+	return nil
+}
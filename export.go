@@ -0,0 +1,109 @@
+package main
+
+import (
+	"io"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// exportGroupRef converts group reference returned by API to reference by name,
+// objectId or ID is used only if group name is unknown
+func exportGroupRef(ref Group, groups map[string]Group) Group {
+	if g, ok := groups[ref.Id]; ok && ref.Name == "" {
+		ref = g
+	}
+	switch {
+	case ref.Name != "":
+		return Group{Name: ref.Name}
+	case ref.ObjectId != "":
+		return Group{ObjectId: ref.ObjectId}
+	}
+	return Group{Id: ref.Id}
+}
+
+func exportGroupRefs(refs []Group, groups map[string]Group) []Group {
+	var ret []Group
+	for _, ref := range refs {
+		ret = append(ret, exportGroupRef(ref, groups))
+	}
+	return ret
+}
+
+func exportFirewallRules(rules []FirewallRule, groups map[string]Group) []FirewallRule {
+	var ret []FirewallRule
+	for _, r := range rules {
+		r.Groups = exportGroupRefs(r.Groups, groups)
+		ret = append(ret, r)
+	}
+	return ret
+}
+
+// groupsById returns all groups by ID for conversion of group references to names
+func groupsById() (map[string]Group, error) {
+	groupList, err := listGroups()
+	if err != nil {
+		return nil, err
+	}
+	groups := map[string]Group{}
+	for _, g := range groupList {
+		groups[g.Id] = g
+	}
+	return groups, nil
+}
+
+// exportManifest reads current firewalls and servers and converts them to manifest
+// which can be applied, IDs are replaced by name references and server-owned fields are removed
+func exportManifest() (*Manifest, error) {
+	groups, err := groupsById()
+	if err != nil {
+		return nil, err
+	}
+	firewalls, err := listFirewalls()
+	if err != nil {
+		return nil, err
+	}
+	servers, err := listServers()
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Manifest{}
+	fwNames := map[string]string{}
+	for _, fw := range firewalls {
+		fwNames[fw.Id] = fw.Name
+		m.Firewalls = append(m.Firewalls, manifestFirewall{Firewall: Firewall{
+			Name:     fw.Name,
+			RulesIn:  exportFirewallRules(fw.RulesIn, groups),
+			RulesOut: exportFirewallRules(fw.RulesOut, groups),
+		}})
+	}
+	for _, s := range servers {
+		fw := Firewall{Name: fwNames[s.Firewall.Id]}
+		if fw.Name == "" {
+			fw.Name = s.Firewall.Name
+		}
+		if fw.Name == "" {
+			fw.Id = s.Firewall.Id
+		}
+		s.Id = ""
+		s.Configuration = ""
+		s.Firewall = fw
+		s.Groups = exportGroupRefs(s.Groups, groups)
+		m.Servers = append(m.Servers, manifestServer{Server: s})
+	}
+	// stable output for version control
+	sort.Slice(m.Firewalls, func(i, j int) bool { return m.Firewalls[i].Name < m.Firewalls[j].Name })
+	sort.Slice(m.Servers, func(i, j int) bool { return m.Servers[i].Name < m.Servers[j].Name })
+	return m, nil
+}
+
+// writeManifest writes manifest (or snippet of manifest) as YAML
+func writeManifest(w io.Writer, m interface{}) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(m); err != nil {
+		return err
+	}
+	return enc.Close()
+}
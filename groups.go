@@ -0,0 +1,246 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// groupResolver resolves group references (group with only one of id, name or objectId)
+// to existing groups
+type groupResolver struct {
+	groups []Group
+}
+
+// cached resolver, groups are listed only once per command
+var cachedGroupResolver *groupResolver
+
+func getGroupResolver() (*groupResolver, error) {
+	if cachedGroupResolver != nil {
+		return cachedGroupResolver, nil
+	}
+	groups, err := listGroups()
+	if err != nil {
+		return nil, err
+	}
+	cachedGroupResolver = &groupResolver{groups: groups}
+	return cachedGroupResolver, nil
+}
+
+// resolve returns group referenced by ref, error contains suggestions for unknown names
+func (r *groupResolver) resolve(ref Group) (Group, error) {
+	var found []Group
+	for _, g := range r.groups {
+		if groupMatches(ref, g) {
+			found = append(found, g)
+		}
+	}
+	switch len(found) {
+	case 1:
+		return found[0], nil
+	case 0:
+		if ref.Id == "" && ref.Name == "" && ref.ObjectId == "" {
+			return Group{}, fmt.Errorf("empty group reference")
+		}
+		msg := fmt.Sprintf("unknown group %s", formatGroup(ref))
+		if ref.Id == "" && ref.Name != "" {
+			if s := r.suggest(ref.Name); len(s) > 0 {
+				msg += fmt.Sprintf(", did you mean %s?", strings.Join(s, " or "))
+			}
+		}
+		return Group{}, fmt.Errorf("%s", msg)
+	}
+	var ids []string
+	for _, g := range found {
+		ids = append(ids, "id="+g.Id)
+	}
+	return Group{}, fmt.Errorf("ambiguous group %s matches %d groups (%s), use group ID", formatGroup(ref), len(found), strings.Join(ids, ", "))
+}
+
+// suggest returns up to 3 group names similar to name
+func (r *groupResolver) suggest(name string) []string {
+	type candidate struct {
+		name     string
+		distance int
+	}
+	var candidates []candidate
+	lname := strings.ToLower(name)
+	for _, g := range r.groups {
+		lg := strings.ToLower(g.Name)
+		d := levenshtein(lname, lg)
+		maxDistance := len(lname) / 3
+		if maxDistance < 2 {
+			maxDistance = 2
+		}
+		if d <= maxDistance || strings.Contains(lg, lname) || strings.Contains(lname, lg) {
+			candidates = append(candidates, candidate{g.Name, d})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].distance < candidates[j].distance })
+	var ret []string
+	for _, c := range candidates {
+		if len(ret) == 3 {
+			break
+		}
+		ret = append(ret, "'"+c.name+"'")
+	}
+	return ret
+}
+
+// levenshtein computes edit distance of strings
+func levenshtein(a string, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min3(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
+
+func min3(a int, b int, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
+
+// groupErrors collects all problems with group references, so they can be reported at once
+type groupErrors []string
+
+func (e groupErrors) Error() string {
+	if len(e) == 1 {
+		return e[0]
+	}
+	return "invalid group references:\n  " + strings.Join(e, "\n  ")
+}
+
+func (e *groupErrors) add(context string, err error) {
+	*e = append(*e, context+": "+err.Error())
+}
+
+func (e groupErrors) err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+func (r *groupResolver) resolveGroups(refs []Group, context string, errs *groupErrors) []Group {
+	var ret []Group
+	for _, ref := range refs {
+		g, err := r.resolve(ref)
+		if err != nil {
+			errs.add(context, err)
+			continue
+		}
+		ret = append(ret, g)
+	}
+	return ret
+}
+
+func (r *groupResolver) resolveFirewall(fw *Firewall, errs *groupErrors) {
+	for i := range fw.RulesIn {
+		ctx := fmt.Sprintf("firewall '%s' rulesIn #%d", fw.Name, i+1)
+		fw.RulesIn[i].Groups = r.resolveGroups(fw.RulesIn[i].Groups, ctx, errs)
+	}
+	for i := range fw.RulesOut {
+		ctx := fmt.Sprintf("firewall '%s' rulesOut #%d", fw.Name, i+1)
+		fw.RulesOut[i].Groups = r.resolveGroups(fw.RulesOut[i].Groups, ctx, errs)
+	}
+}
+
+func (r *groupResolver) resolveServer(server *Server, errs *groupErrors) {
+	server.Groups = r.resolveGroups(server.Groups, fmt.Sprintf("server '%s'", server.Name), errs)
+}
+
+// copyRules copies rules, so resolving groups does not change rules of caller
+func copyRules(rules []FirewallRule) []FirewallRule {
+	var ret []FirewallRule
+	for _, r := range rules {
+		r.Groups = append([]Group(nil), r.Groups...)
+		ret = append(ret, r)
+	}
+	return ret
+}
+
+// resolveFirewallGroups replaces group references in firewall rules by existing groups
+func resolveFirewallGroups(fw Firewall) (Firewall, error) {
+	r, err := getGroupResolver()
+	if err != nil {
+		return fw, err
+	}
+	fw.RulesIn = copyRules(fw.RulesIn)
+	fw.RulesOut = copyRules(fw.RulesOut)
+	var errs groupErrors
+	r.resolveFirewall(&fw, &errs)
+	return fw, errs.err()
+}
+
+// resolveServerGroups replaces group references of server by existing groups
+func resolveServerGroups(server Server) (Server, error) {
+	r, err := getGroupResolver()
+	if err != nil {
+		return server, err
+	}
+	var errs groupErrors
+	r.resolveServer(&server, &errs)
+	return server, errs.err()
+}
+
+// resolveManifestGroups replaces all group references in manifest by existing groups,
+// all unknown and ambiguous groups are reported in one error
+func resolveManifestGroups(m *Manifest) error {
+	r, err := getGroupResolver()
+	if err != nil {
+		return err
+	}
+	var errs groupErrors
+	for i := range m.Firewalls {
+		r.resolveFirewall(&m.Firewalls[i].Firewall, &errs)
+	}
+	for i := range m.Servers {
+		r.resolveServer(&m.Servers[i].Server, &errs)
+	}
+	return errs.err()
+}
+
+// normalizeManifestGroups replaces group references in manifest by references by group ID
+func normalizeManifestGroups(m *Manifest) error {
+	if err := resolveManifestGroups(m); err != nil {
+		return err
+	}
+	byId := func(groups []Group) []Group {
+		var ret []Group
+		for _, g := range groups {
+			ret = append(ret, Group{Id: g.Id})
+		}
+		return ret
+	}
+	for i := range m.Firewalls {
+		for j := range m.Firewalls[i].RulesIn {
+			m.Firewalls[i].RulesIn[j].Groups = byId(m.Firewalls[i].RulesIn[j].Groups)
+		}
+		for j := range m.Firewalls[i].RulesOut {
+			m.Firewalls[i].RulesOut[j].Groups = byId(m.Firewalls[i].RulesOut[j].Groups)
+		}
+	}
+	for i := range m.Servers {
+		m.Servers[i].Groups = byId(m.Servers[i].Groups)
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// importSnippet is manifest with imported firewall or server, rules and listeners are written
+// in compact format of CLI flags, items which compact format can not represent are written as objects
+type importSnippet struct {
+	Firewalls []importedFirewall `yaml:"firewalls,omitempty"`
+	Servers   []importedServer   `yaml:"servers,omitempty"`
+}
+
+type importedFirewall struct {
+	Key      string        `yaml:"key,omitempty"`
+	Name     string        `yaml:"name"`
+	RulesIn  []interface{} `yaml:"rulesIn,omitempty"`
+	RulesOut []interface{} `yaml:"rulesOut,omitempty"`
+}
+
+type importedServer struct {
+	Key            string                   `yaml:"key,omitempty"`
+	Name           string                   `yaml:"name"`
+	Groups         []Group                  `yaml:"groups,omitempty"`
+	Firewall       Firewall                 `yaml:"firewall"`
+	Listeners      []interface{}            `yaml:"listeners,omitempty"`
+	Autoupdate     bool                     `yaml:"autoupdate,omitempty"`
+	IpAddress      string                   `yaml:"ipAddress,omitempty"`
+	Description    string                   `yaml:"description,omitempty"`
+	OSUpdatePolicy ServerOSAutoupdatePolicy `yaml:"osUpdatePolicy,omitempty"`
+}
+
+// bindStateEntry binds ID to key in state entries, key can not be bound to other ID
+// and ID can not be bound to other key
+func bindStateEntry(entries map[string]stateEntry, kind string, key string, id string, name string) error {
+	if e, ok := entries[key]; ok && e.Id != id {
+		return fmt.Errorf("%s key '%s' is already bound to ID %s", kind, key, e.Id)
+	}
+	for k, e := range entries {
+		if e.Id == id && k != key {
+			return fmt.Errorf("%s %s is already imported as key '%s'", kind, id, k)
+		}
+	}
+	entries[key] = stateEntry{Id: id, Name: name}
+	return nil
+}
+
+// compactSafe checks if values can be written in compact format, its separators can not be escaped
+func compactSafe(values ...string) bool {
+	for _, v := range values {
+		if strings.ContainsAny(v, ",;") {
+			return false
+		}
+	}
+	return true
+}
+
+func compactRules(rules []FirewallRule, groups map[string]Group) []interface{} {
+	var ret []interface{}
+	for _, r := range exportFirewallRules(rules, groups) {
+		values := []string{r.Protocol, r.Port, r.Host}
+		for _, g := range r.Groups {
+			values = append(values, formatGroup(g))
+		}
+		if compactSafe(values...) {
+			ret = append(ret, formatFirewallRule(r))
+		} else {
+			ret = append(ret, r)
+		}
+	}
+	return ret
+}
+
+func compactListeners(listeners []Listener) []interface{} {
+	var ret []interface{}
+	for _, l := range listeners {
+		if compactSafe(l.Protocol, l.ForwardHost, l.Description) {
+			ret = append(ret, formatListener(l))
+		} else {
+			ret = append(ret, l)
+		}
+	}
+	return ret
+}
+
+// importFirewall binds existing firewall to manifest key in state and returns manifest snippet with it,
+// firewall name is used as key if key is empty
+func importFirewall(st *stateFile, id string, key string) (*importSnippet, error) {
+	fw, err := getFirewall("", id)
+	if err != nil {
+		return nil, err
+	}
+	if fw == nil {
+		return nil, fmt.Errorf("firewall %s not found", id)
+	}
+	groups, err := groupsById()
+	if err != nil {
+		return nil, err
+	}
+	if key == "" {
+		key = fw.Name
+	}
+	if err := bindStateEntry(st.Firewalls, "firewall", key, fw.Id, fw.Name); err != nil {
+		return nil, err
+	}
+	imported := importedFirewall{
+		Name:     fw.Name,
+		RulesIn:  compactRules(fw.RulesIn, groups),
+		RulesOut: compactRules(fw.RulesOut, groups),
+	}
+	if key != fw.Name {
+		imported.Key = key
+	}
+	return &importSnippet{Firewalls: []importedFirewall{imported}}, nil
+}
+
+// importServer binds existing server to manifest key in state and returns manifest snippet with it,
+// server name is used as key if key is empty, firewall is referenced by name
+func importServer(st *stateFile, id string, key string) (*importSnippet, error) {
+	s, err := getServer("", id)
+	if err != nil {
+		return nil, err
+	}
+	if s == nil {
+		return nil, fmt.Errorf("server %s not found", id)
+	}
+	groups, err := groupsById()
+	if err != nil {
+		return nil, err
+	}
+	fw := Firewall{Name: s.Firewall.Name}
+	if fw.Name == "" {
+		current, err := getFirewall("", s.Firewall.Id)
+		if err != nil {
+			return nil, err
+		}
+		if current != nil {
+			fw.Name = current.Name
+		} else {
+			fw.Id = s.Firewall.Id
+		}
+	}
+	if key == "" {
+		key = s.Name
+	}
+	if err := bindStateEntry(st.Servers, "server", key, s.Id, s.Name); err != nil {
+		return nil, err
+	}
+	imported := importedServer{
+		Name:           s.Name,
+		Groups:         exportGroupRefs(s.Groups, groups),
+		Firewall:       fw,
+		Listeners:      compactListeners(s.Listeners),
+		Autoupdate:     s.Autoupdate,
+		IpAddress:      s.IpAddress,
+		Description:    s.Description,
+		OSUpdatePolicy: s.OSUpdatePolicy,
+	}
+	if key != s.Name {
+		imported.Key = key
+	}
+	return &importSnippet{Servers: []importedServer{imported}}, nil
+}
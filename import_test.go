@@ -0,0 +1,58 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// snippet has to be loaded as manifest with the same rules and listeners,
+// items with separators of compact format are written as objects
+func TestImportSnippetRoundTrip(t *testing.T) {
+	groups := map[string]Group{
+		"g1": {Id: "g1", Name: "admins"},
+		"g2": {Id: "g2", Name: "ops;night"},
+		"g3": {Id: "g3", Name: "a,b"},
+	}
+	rules := []FirewallRule{
+		{Protocol: "tcp", Port: "22", Host: "group", Groups: []Group{{Id: "g1"}}},
+		{Protocol: "tcp", Port: "22", Host: "group", Groups: []Group{{Id: "g1"}, {Id: "g2"}}},
+		{Protocol: "udp", Port: "53", Host: "group", Groups: []Group{{Id: "g3"}}},
+		{Protocol: "any", Port: "any", Host: "any"},
+	}
+	listeners := []Listener{
+		{ListenPort: 80, Protocol: "tcp", ForwardPort: 8080, ForwardHost: "web.local"},
+		{ListenPort: 443, Protocol: "tcp", ForwardPort: 8443, ForwardHost: "web.local", Description: "https; public"},
+		{ListenPort: 5432, Protocol: "tcp", ForwardPort: 5432, ForwardHost: "db.local", Description: "primary, replica"},
+	}
+	snippet := importSnippet{
+		Firewalls: []importedFirewall{{Name: "web", RulesIn: compactRules(rules, groups)}},
+		Servers:   []importedServer{{Name: "web-1", Firewall: Firewall{Name: "web"}, Listeners: compactListeners(listeners)}},
+	}
+	var compact int
+	for _, item := range append(snippet.Firewalls[0].RulesIn, snippet.Servers[0].Listeners...) {
+		if _, ok := item.(string); ok {
+			compact++
+		}
+	}
+	if compact != 3 {
+		t.Errorf("%d items in compact format, want 3", compact)
+	}
+
+	var buf bytes.Buffer
+	if err := writeManifest(&buf, &snippet); err != nil {
+		t.Fatal(err)
+	}
+	var m Manifest
+	if err := yaml.Unmarshal(buf.Bytes(), &m); err != nil {
+		t.Fatalf("snippet can not be loaded: %s\n%s", err, buf.String())
+	}
+	if want := exportFirewallRules(rules, groups); !reflect.DeepEqual(m.Firewalls[0].RulesIn, want) {
+		t.Errorf("loaded rules %+v, want %+v", m.Firewalls[0].RulesIn, want)
+	}
+	if !reflect.DeepEqual(m.Servers[0].Listeners, listeners) {
+		t.Errorf("loaded listeners %+v, want %+v", m.Servers[0].Listeners, listeners)
+	}
+}
@@ -0,0 +1,311 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Simple JSONPath template evaluator compatible with subset of kubectl jsonpath output:
+//
+//	{.id}                               field of object
+//	{.listeners[0].listenPort}          item of array
+//	{[*].name}                          all items of array (list commands return array)
+//	{.rulesIn[?(@.protocol=="tcp")]}    items of array matching filter (only == is supported)
+//	{.*}                                values of all fields, ordered by field name
+//	{range [*]}{.name}{"\n"}{end}       iteration
+//
+// Template is evaluated against JSON representation of data, so field names are
+// the same as in JSON output.
+
+type jsonpathNode struct {
+	text    string // literal text
+	path    string // expression inside {}
+	isRange bool
+	isEnd   bool
+}
+
+func parseJsonpath(template string) ([]jsonpathNode, error) {
+	var nodes []jsonpathNode
+	for len(template) > 0 {
+		start := strings.Index(template, "{")
+		if start < 0 {
+			nodes = append(nodes, jsonpathNode{text: template})
+			break
+		}
+		if start > 0 {
+			nodes = append(nodes, jsonpathNode{text: template[:start]})
+		}
+		end := jsonpathExprEnd(template, start)
+		if end < 0 {
+			return nil, fmt.Errorf("unclosed expression in jsonpath: %s", template[start:])
+		}
+		expr := strings.TrimSpace(template[start+1 : end])
+		template = template[end+1:]
+		switch {
+		case expr == "end":
+			nodes = append(nodes, jsonpathNode{isEnd: true})
+		case strings.HasPrefix(expr, "range "):
+			nodes = append(nodes, jsonpathNode{path: strings.TrimSpace(strings.TrimPrefix(expr, "range ")), isRange: true})
+		case strings.HasPrefix(expr, `"`):
+			text, err := strconv.Unquote(expr)
+			if err != nil {
+				return nil, fmt.Errorf("invalid string in jsonpath: %s", expr)
+			}
+			nodes = append(nodes, jsonpathNode{text: text})
+		default:
+			nodes = append(nodes, jsonpathNode{path: expr})
+		}
+	}
+	return nodes, nil
+}
+
+// jsonpathExprEnd finds closing brace of expression, braces inside strings are ignored
+func jsonpathExprEnd(template string, start int) int {
+	quoted := false
+	for i := start + 1; i < len(template); i++ {
+		switch template[i] {
+		case '\\':
+			i++
+		case '"':
+			quoted = !quoted
+		case '}':
+			if !quoted {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// executeJsonpath evaluates template against data, data is converted to its JSON representation
+func executeJsonpath(template string, data interface{}) (string, error) {
+	nodes, err := parseJsonpath(template)
+	if err != nil {
+		return "", err
+	}
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return "", err
+	}
+	var root interface{}
+	if err := json.Unmarshal(raw, &root); err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	rest, err := evalJsonpathNodes(&buf, nodes, root)
+	if err != nil {
+		return "", err
+	}
+	if len(rest) > 0 {
+		return "", fmt.Errorf("unexpected {end} in jsonpath")
+	}
+	return buf.String(), nil
+}
+
+// evalJsonpathNodes evaluates nodes until {end} or end of template,
+// returns nodes following the {end}
+func evalJsonpathNodes(buf *bytes.Buffer, nodes []jsonpathNode, current interface{}) ([]jsonpathNode, error) {
+	for len(nodes) > 0 {
+		n := nodes[0]
+		nodes = nodes[1:]
+		switch {
+		case n.isEnd:
+			return append([]jsonpathNode{n}, nodes...), nil
+		case n.isRange:
+			items, err := evalJsonpath(n.path, current)
+			if err != nil {
+				return nil, err
+			}
+			body := nodes
+			var rest []jsonpathNode
+			if len(items) == 0 {
+				// skip body of range
+				var discard bytes.Buffer
+				rest, err = evalJsonpathNodes(&discard, body, nil)
+				if err != nil {
+					return nil, err
+				}
+			}
+			for _, item := range items {
+				rest, err = evalJsonpathNodes(buf, body, item)
+				if err != nil {
+					return nil, err
+				}
+			}
+			if len(rest) == 0 {
+				return nil, fmt.Errorf("missing {end} of {range %s} in jsonpath", n.path)
+			}
+			nodes = rest[1:]
+		case n.path != "":
+			values, err := evalJsonpath(n.path, current)
+			if err != nil {
+				return nil, err
+			}
+			for i, v := range values {
+				if i > 0 {
+					buf.WriteString(" ")
+				}
+				buf.WriteString(jsonpathValueString(v))
+			}
+		default:
+			buf.WriteString(n.text)
+		}
+	}
+	return nil, nil
+}
+
+func jsonpathValueString(v interface{}) string {
+	switch x := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return x
+	case map[string]interface{}, []interface{}:
+		data, _ := json.Marshal(x)
+		return string(data)
+	}
+	return fmt.Sprint(v)
+}
+
+// evalJsonpath evaluates path (for example .listeners[*].listenPort) and returns all matching values
+func evalJsonpath(path string, current interface{}) ([]interface{}, error) {
+	path = strings.TrimPrefix(path, "$")
+	values := []interface{}{current}
+	for path != "" {
+		var next []interface{}
+		switch {
+		case strings.HasPrefix(path, "["):
+			end := jsonpathBracketEnd(path)
+			if end < 0 {
+				return nil, fmt.Errorf("unclosed [ in jsonpath: %s", path)
+			}
+			sel := path[1:end]
+			path = path[end+1:]
+			for _, v := range values {
+				arr, ok := v.([]interface{})
+				if !ok {
+					continue
+				}
+				items, err := jsonpathSelect(arr, sel)
+				if err != nil {
+					return nil, err
+				}
+				next = append(next, items...)
+			}
+		case strings.HasPrefix(path, "."):
+			path = path[1:]
+			end := strings.IndexAny(path, ".[")
+			if end < 0 {
+				end = len(path)
+			}
+			field := path[:end]
+			path = path[end:]
+			for _, v := range values {
+				if field == "" {
+					next = append(next, v)
+					continue
+				}
+				switch x := v.(type) {
+				case map[string]interface{}:
+					if field == "*" {
+						// map order is random, values are returned in order of keys
+						keys := make([]string, 0, len(x))
+						for k := range x {
+							keys = append(keys, k)
+						}
+						sort.Strings(keys)
+						for _, k := range keys {
+							next = append(next, x[k])
+						}
+					} else if item, ok := x[field]; ok {
+						next = append(next, item)
+					}
+				case []interface{}:
+					if field == "*" {
+						next = append(next, x...)
+					}
+				}
+			}
+		default:
+			return nil, fmt.Errorf("invalid jsonpath: %s", path)
+		}
+		values = next
+	}
+	return values, nil
+}
+
+// jsonpathBracketEnd finds ] matching [ at start of path, nested brackets (filter with index,
+// for example [?(@.groups[0].name=="admins")]) and brackets inside strings are skipped
+func jsonpathBracketEnd(path string) int {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(path); i++ {
+		c := path[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '[':
+			depth++
+		case c == ']':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// jsonpathSelect selects items of array by index, * or filter ?(@.field=="value")
+func jsonpathSelect(arr []interface{}, sel string) ([]interface{}, error) {
+	sel = strings.TrimSpace(sel)
+	switch {
+	case sel == "*":
+		return arr, nil
+	case strings.HasPrefix(sel, "?(") && strings.HasSuffix(sel, ")"):
+		filter := sel[2 : len(sel)-1]
+		parts := strings.SplitN(filter, "==", 2)
+		if len(parts) != 2 || !strings.HasPrefix(strings.TrimSpace(parts[0]), "@") {
+			return nil, fmt.Errorf("unsupported jsonpath filter: %s", filter)
+		}
+		fieldPath := strings.TrimPrefix(strings.TrimSpace(parts[0]), "@")
+		expected := strings.TrimSpace(parts[1])
+		if s, err := strconv.Unquote(expected); err == nil {
+			expected = s
+		} else {
+			expected = strings.Trim(expected, "'")
+		}
+		var ret []interface{}
+		for _, item := range arr {
+			values, err := evalJsonpath(fieldPath, item)
+			if err != nil {
+				return nil, err
+			}
+			if len(values) > 0 && jsonpathValueString(values[0]) == expected {
+				ret = append(ret, item)
+			}
+		}
+		return ret, nil
+	}
+	idx, err := strconv.Atoi(sel)
+	if err != nil {
+		return nil, fmt.Errorf("invalid array index in jsonpath: %s", sel)
+	}
+	if idx < 0 {
+		idx += len(arr)
+	}
+	if idx < 0 || idx >= len(arr) {
+		return nil, nil
+	}
+	return []interface{}{arr[idx]}, nil
+}
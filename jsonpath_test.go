@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestExecuteJsonpath(t *testing.T) {
+	admins := Group{Id: "g:1", Name: "admins"}
+	ops := Group{Id: "g:2", Name: "ops"}
+	fw := Firewall{Id: "fw:1", Name: "web", RulesIn: []FirewallRule{
+		{Protocol: "tcp", Port: "22", Host: "group", Groups: []Group{admins, ops}},
+		{Protocol: "tcp", Port: "443", Host: "any"},
+		{Protocol: "udp", Port: "53", Host: "group", Groups: []Group{ops}},
+	}}
+	servers := []Server{
+		{Id: "srv:1", Name: "web-1", Firewall: Firewall{Id: "fw:1"}, Groups: []Group{admins},
+			Listeners: []Listener{{ListenPort: 80, Protocol: "tcp", ForwardPort: 8080, ForwardHost: "a"}, {ListenPort: 443, Protocol: "tcp", ForwardPort: 8443, ForwardHost: "b"}}},
+		{Id: "srv:2", Name: "db-1", Firewall: Firewall{Id: "fw:2"}},
+	}
+	tests := []struct {
+		name     string
+		template string
+		data     interface{}
+		want     string
+		wantErr  bool
+	}{
+		{name: "id of ensured server", template: "{.id}", data: servers[0], want: "srv:1"},
+		{name: "nested field", template: "{.firewall.id}", data: servers[0], want: "fw:1"},
+		{name: "index", template: "{.listeners[1].listenPort}", data: servers[0], want: "443"},
+		{name: "negative index", template: "{.listeners[-1].forwardHost}", data: servers[0], want: "b"},
+		{name: "index out of range", template: "{.listeners[5].forwardHost}", data: servers[0], want: ""},
+		{name: "all items of list", template: "{[*].name}", data: servers, want: "web-1 db-1"},
+		{name: "all items of nested array", template: "{.listeners[*].forwardPort}", data: servers[0], want: "8080 8443"},
+		{name: "filter", template: `{.rulesIn[?(@.protocol=="tcp")].port}`, data: fw, want: "22 443"},
+		{name: "filter with single quotes", template: `{.rulesIn[?(@.host=='any')].port}`, data: fw, want: "443"},
+		{name: "filter with nested index", template: `{.rulesIn[?(@.groups[0].name=="ops")].port}`, data: fw, want: "53"},
+		{name: "filter with bracket in string", template: `{.rulesIn[?(@.port=="a]")].port}`, data: fw, want: ""},
+		{name: "object is printed as JSON", template: "{.groups[0]}", data: servers[0], want: `{"description":"","id":"g:1","name":"admins","objectId":""}`},
+		{name: "map values are ordered by key", template: "{.groups[0].*}", data: servers[0], want: " g:1 admins "},
+		{name: "range", template: `{range [*]}{.name}={.firewall.id}{"\n"}{end}`, data: servers, want: "web-1=fw:1\ndb-1=fw:2\n"},
+		{name: "nested range", template: `{range .rulesIn[*]}{.port}:{range .groups[*]}{.name},{end};{end}`, data: fw, want: "22:admins,ops,;443:;53:ops,;"},
+		{name: "text around expression", template: "id={.id} name={.name}", data: fw, want: "id=fw:1 name=web"},
+		{name: "unclosed expression", template: "{.id", data: fw, wantErr: true},
+		{name: "unclosed bracket", template: "{.rulesIn[0}", data: fw, wantErr: true},
+		{name: "missing end", template: "{range .rulesIn[*]}{.port}", data: fw, wantErr: true},
+		{name: "unexpected end", template: "{.id}{end}", data: fw, wantErr: true},
+		{name: "invalid index", template: "{.rulesIn[x]}", data: fw, wantErr: true},
+		{name: "unsupported filter", template: `{.rulesIn[?(@.port!="22")]}`, data: fw, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := executeJsonpath(tt.template, tt.data)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// ensure -o jsonpath='{.id}' prints only ID, so it can be used in scripts
+func TestPrintOutputJsonpath(t *testing.T) {
+	format := outputFormat
+	defer func() { outputFormat = format }()
+	outputFormat = "jsonpath={.id}"
+	var buf bytes.Buffer
+	if err := printOutput(&buf, Server{Id: "srv:1", Name: "web-1"}); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "srv:1\n" {
+		t.Errorf("got %q, want %q", buf.String(), "srv:1\n")
+	}
+}
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// exit code of lint command if there is finding with --fail-on or higher severity (1 is used for errors)
+const exitLintFindings = 2
+
+const (
+	severityOff     = "off"
+	severityInfo    = "info"
+	severityWarning = "warning"
+	severityError   = "error"
+)
+
+// order of severities, used for --fail-on
+var severityLevels = map[string]int{
+	severityOff:     0,
+	severityInfo:    1,
+	severityWarning: 2,
+	severityError:   3,
+}
+
+// lint checks and their default severities
+const (
+	lintDuplicate     = "duplicate"
+	lintShadowed      = "shadowed"
+	lintOverlap       = "overlap"
+	lintAnyAnyAny     = "any-any-any"
+	lintEmptyGroups   = "group-without-groups"
+	lintUnknownGroup  = "unknown-group"
+	lintInvertedRange = "inverted-range"
+)
+
+var defaultLintSeverities = map[string]string{
+	lintDuplicate:     severityWarning,
+	lintShadowed:      severityWarning,
+	lintOverlap:       severityInfo,
+	lintAnyAnyAny:     severityError,
+	lintEmptyGroups:   severityError,
+	lintUnknownGroup:  severityError,
+	lintInvertedRange: severityError,
+}
+
+// lintFinding is one problem found in firewall rules
+type lintFinding struct {
+	Severity string
+	Check    string
+	Firewall string
+	Field    string
+	Index    int
+	Rule     FirewallRule
+	Message  string
+}
+
+func (f lintFinding) String() string {
+	return fmt.Sprintf("%s: firewall '%s' %s #%d (%s): %s [%s]",
+		f.Severity, f.Firewall, f.Field, f.Index+1, formatFirewallRule(f.Rule), f.Message, f.Check)
+}
+
+type linter struct {
+	// severities of checks
+	severities map[string]string
+	// existing groups, nil if groups are not checked
+	groups   []Group
+	findings []lintFinding
+}
+
+func newLinter() *linter {
+	l := &linter{severities: map[string]string{}}
+	for k, v := range defaultLintSeverities {
+		l.severities[k] = v
+	}
+	return l
+}
+
+// setSeverities overrides severities of checks, items are in format check=severity
+func (l *linter) setSeverities(items []string) error {
+	for _, item := range items {
+		check, severity, ok := strings.Cut(item, "=")
+		if !ok {
+			return fmt.Errorf("invalid severity '%s', expected format check=severity", item)
+		}
+		if _, ok := defaultLintSeverities[check]; !ok {
+			return fmt.Errorf("unknown check '%s', known checks are %s", check, strings.Join(lintChecks(), ", "))
+		}
+		if _, ok := severityLevels[severity]; !ok {
+			return fmt.Errorf("invalid severity '%s', use off, info, warning or error", severity)
+		}
+		l.severities[check] = severity
+	}
+	return nil
+}
+
+func lintChecks() []string {
+	var ret []string
+	for k := range defaultLintSeverities {
+		ret = append(ret, k)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+func (l *linter) report(check string, fw string, field string, index int, rule FirewallRule, format string, args ...interface{}) {
+	severity := l.severities[check]
+	if severity == severityOff {
+		return
+	}
+	l.findings = append(l.findings, lintFinding{
+		Severity: severity,
+		Check:    check,
+		Firewall: fw,
+		Field:    field,
+		Index:    index,
+		Rule:     rule,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// portRange returns range of ports of rule, any is 1-65535
+func portRange(port string) (int, int, bool) {
+	if port == "any" {
+		return 1, 65535, true
+	}
+	from, to, isRange := strings.Cut(port, "-")
+	lo, err := strconv.Atoi(from)
+	if err != nil {
+		return 0, 0, false
+	}
+	if !isRange {
+		return lo, lo, true
+	}
+	hi, err := strconv.Atoi(to)
+	if err != nil {
+		return 0, 0, false
+	}
+	return lo, hi, true
+}
+
+// matchesNothing checks if rule is group rule without groups, such rule allows no traffic
+// and it is reported by group-without-groups check, not as shadowed or overlapping
+func matchesNothing(r FirewallRule) bool {
+	return r.Host == "group" && len(r.Groups) == 0
+}
+
+// ruleCovers checks if rule a allows all traffic allowed by rule b
+func ruleCovers(a FirewallRule, b FirewallRule) bool {
+	if matchesNothing(a) || matchesNothing(b) {
+		return false
+	}
+	if a.Protocol != "any" && a.Protocol != b.Protocol {
+		return false
+	}
+	alo, ahi, aok := portRange(a.Port)
+	blo, bhi, bok := portRange(b.Port)
+	if !aok || !bok || alo > blo || ahi < bhi {
+		return false
+	}
+	if a.Host == "any" {
+		return true
+	}
+	if b.Host != "group" {
+		return false
+	}
+	for _, g := range b.Groups {
+		if !containsGroup(a.Groups, g) {
+			return false
+		}
+	}
+	return true
+}
+
+// rulesOverlap checks if port ranges of rules with the same protocol and hosts overlap
+func rulesOverlap(a FirewallRule, b FirewallRule) bool {
+	if matchesNothing(a) || matchesNothing(b) {
+		return false
+	}
+	if a.Protocol != b.Protocol || a.Host != b.Host || !sameGroups(a.Groups, b.Groups) {
+		return false
+	}
+	alo, ahi, aok := portRange(a.Port)
+	blo, bhi, bok := portRange(b.Port)
+	return aok && bok && alo <= bhi && blo <= ahi
+}
+
+func (l *linter) lintRules(fw string, field string, rules []FirewallRule) {
+	for i, r := range rules {
+		if lo, hi, ok := portRange(r.Port); ok && lo > hi {
+			l.report(lintInvertedRange, fw, field, i, r, "port range %s is inverted", r.Port)
+		}
+		if field == "rulesIn" && r.Protocol == "any" && r.Port == "any" && r.Host == "any" {
+			l.report(lintAnyAnyAny, fw, field, i, r, "rule allows all inbound traffic from any host")
+		}
+		if matchesNothing(r) {
+			l.report(lintEmptyGroups, fw, field, i, r, "host is group, but no groups are specified")
+		}
+		if l.groups != nil {
+			for _, g := range r.Groups {
+				if !containsGroup(l.groups, g) {
+					l.report(lintUnknownGroup, fw, field, i, r, "group %s does not exist", formatGroup(g))
+				}
+			}
+		}
+		l.lintRedundancy(fw, field, rules, i)
+	}
+}
+
+// lintRedundancy reports rule which is duplicate of previous rule or which is covered by other
+// broader rule (rules only allow traffic, so such rule has no effect) and overlaps with previous rules
+func (l *linter) lintRedundancy(fw string, field string, rules []FirewallRule, i int) {
+	r := rules[i]
+	for j := 0; j < i; j++ {
+		if sameFirewallRule(rules[j], r) {
+			l.report(lintDuplicate, fw, field, i, r, "duplicate of rule #%d", j+1)
+			return
+		}
+	}
+	for j, other := range rules {
+		if j != i && !sameFirewallRule(other, r) && ruleCovers(other, r) {
+			l.report(lintShadowed, fw, field, i, r, "rule is shadowed by broader rule #%d (%s)", j+1, formatFirewallRule(other))
+			return
+		}
+	}
+	for j := 0; j < i; j++ {
+		if rulesOverlap(rules[j], r) && !ruleCovers(r, rules[j]) {
+			l.report(lintOverlap, fw, field, i, r, "port range overlaps with rule #%d (%s)", j+1, formatFirewallRule(rules[j]))
+		}
+	}
+}
+
+func (l *linter) lintFirewall(fw Firewall) {
+	l.lintRules(fw.Name, "rulesIn", fw.RulesIn)
+	l.lintRules(fw.Name, "rulesOut", fw.RulesOut)
+}
+
+// failed checks if there is finding with at least given severity
+func (l *linter) failed(failOn string) bool {
+	for _, f := range l.findings {
+		if severityLevels[f.Severity] >= severityLevels[failOn] {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *linter) print(w io.Writer) {
+	counts := map[string]int{}
+	for _, f := range l.findings {
+		fmt.Fprintln(w, f)
+		counts[f.Severity]++
+	}
+	if len(l.findings) == 0 {
+		fmt.Fprintln(w, "No problems found.")
+		return
+	}
+	fmt.Fprintf(w, "%d errors, %d warnings, %d infos\n", counts[severityError], counts[severityWarning], counts[severityInfo])
+}
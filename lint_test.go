@@ -0,0 +1,51 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestRuleCovers(t *testing.T) {
+	admins := []Group{{Name: "admins"}}
+	both := []Group{{Name: "admins"}, {Name: "ops"}}
+	tests := []struct {
+		name string
+		a    FirewallRule
+		b    FirewallRule
+		want bool
+	}{
+		{"any host covers group", FirewallRule{Protocol: "tcp", Port: "any", Host: "any"}, FirewallRule{Protocol: "tcp", Port: "22", Host: "group", Groups: admins}, true},
+		{"port range covers port", FirewallRule{Protocol: "tcp", Port: "20-30", Host: "any"}, FirewallRule{Protocol: "tcp", Port: "22", Host: "any"}, true},
+		{"other protocol", FirewallRule{Protocol: "udp", Port: "any", Host: "any"}, FirewallRule{Protocol: "tcp", Port: "22", Host: "any"}, false},
+		{"more groups cover fewer", FirewallRule{Protocol: "tcp", Port: "22", Host: "group", Groups: both}, FirewallRule{Protocol: "tcp", Port: "22", Host: "group", Groups: admins}, true},
+		{"fewer groups do not cover more", FirewallRule{Protocol: "tcp", Port: "22", Host: "group", Groups: admins}, FirewallRule{Protocol: "tcp", Port: "22", Host: "group", Groups: both}, false},
+		{"group does not cover any host", FirewallRule{Protocol: "tcp", Port: "22", Host: "group", Groups: admins}, FirewallRule{Protocol: "tcp", Port: "22", Host: "any"}, false},
+		{"group without groups covers nothing", FirewallRule{Protocol: "tcp", Port: "22", Host: "group"}, FirewallRule{Protocol: "tcp", Port: "22", Host: "group"}, false},
+		{"group without groups is not covered", FirewallRule{Protocol: "any", Port: "any", Host: "any"}, FirewallRule{Protocol: "tcp", Port: "22", Host: "group"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ruleCovers(tt.a, tt.b); got != tt.want {
+				t.Errorf("ruleCovers = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLintEmptyGroups(t *testing.T) {
+	l := newLinter()
+	l.lintFirewall(Firewall{Name: "web", RulesIn: []FirewallRule{
+		{Protocol: "tcp", Port: "22", Host: "group"},
+		{Protocol: "tcp", Port: "22-23", Host: "group"},
+		{Protocol: "tcp", Port: "any", Host: "any"},
+	}})
+	var checks []string
+	for _, f := range l.findings {
+		if f.Index < 2 {
+			checks = append(checks, f.Check)
+		}
+	}
+	// empty group rules are reported only as rules without groups, not as shadowed or overlapping
+	if len(checks) != 2 || checks[0] != lintEmptyGroups || checks[1] != lintEmptyGroups {
+		t.Errorf("findings %v, want two %s", l.findings, lintEmptyGroups)
+	}
+}
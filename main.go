@@ -1,8 +1,10 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
 
 	"github.com/spf13/cobra"
 )
@@ -11,20 +13,26 @@ var shieldooUri = ""
 var shieldooApiKey = ""
 
 func init() {
+	rootCmd.PersistentFlags().StringVar(&profileName, "profile", "", "Name of config profile (context) to use, SHIELDOO_PROFILE environment variable can be used too")
+	rootCmd.PersistentFlags().DurationVar(&apiTimeout, "timeout", apiTimeout, "Timeout of one API request")
+	rootCmd.PersistentFlags().IntVar(&apiRetries, "retries", apiRetries, "Maximum number of retries of failed API request (connection errors, 429 and 5xx responses)")
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", outputFormat, "Output format of list, show and ensure commands: "+outputFormats)
 	rootCmd.AddCommand(initServerCmd())
 	rootCmd.AddCommand(initFirewallCmd())
 	rootCmd.AddCommand(initGroupCmd())
-	// load env variables
-	shieldooUri = os.Getenv("SHIELDOO_URI")
-	if shieldooUri == "" {
-		fmt.Println("Error: SHIELDOO_URI environment variable not set")
-		os.Exit(1)
-	}
-	shieldooApiKey = os.Getenv("SHIELDOO_APIKEY")
-	if shieldooApiKey == "" {
-		fmt.Println("Error: SHIELDOO_APIKEY environment variable not set")
-		os.Exit(1)
-	}
+	rootCmd.AddCommand(initApplyCmd())
+	rootCmd.AddCommand(initPlanCmd())
+	rootCmd.AddCommand(initDriftCmd())
+	rootCmd.AddCommand(initExportCmd())
+	rootCmd.AddCommand(initNormalizeCmd())
+	rootCmd.AddCommand(initStateCmd())
+	rootCmd.AddCommand(initImportCmd())
+	rootCmd.AddCommand(initReachCmd())
+	rootCmd.AddCommand(initReportCmd())
+	rootCmd.AddCommand(initConfigCmd())
+	rootCmd.AddCommand(initLoginCmd())
+	rootCmd.AddCommand(initLogoutCmd())
+	rootCmd.AddCommand(initDevServerCmd())
 }
 
 var rootCmd = &cobra.Command{
@@ -34,7 +42,10 @@ var rootCmd = &cobra.Command{
 }
 
 func main() {
-	if err := rootCmd.Execute(); err != nil {
+	// API calls are cancelled by Ctrl+C
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if err := rootCmd.ExecuteContext(ctx); err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
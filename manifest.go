@@ -0,0 +1,428 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// Manifest describes desired state of firewalls and servers in tenant.
+// Manifest can be written in YAML or JSON (JSON is subset of YAML),
+// servers reference firewalls by name or by ID, for example:
+//
+//	firewalls:
+//	  - name: web
+//	    rulesIn:
+//	      - protocol: tcp
+//	        port: "443"
+//	        host: any
+//	servers:
+//	  - name: web-1
+//	    key: web-frontend
+//	    firewall:
+//	      name: web
+//	protected:
+//	  - legacy-*
+//
+// Optional key identifies resource in state file, so resource with key can be renamed.
+// Manifest can be split to multiple files and YAML documents, they are merged
+// and ${NAME} variables are substituted in values (see substitute).
+type Manifest struct {
+	Firewalls []manifestFirewall `yaml:"firewalls"`
+	Servers   []manifestServer   `yaml:"servers"`
+	// names of servers and firewalls which are never deleted by prune
+	Protected []string `yaml:"protected,omitempty"`
+}
+
+// manifestFirewall is firewall with key which identifies it in state file
+type manifestFirewall struct {
+	Key      string `yaml:"key,omitempty"`
+	Firewall `yaml:",inline"`
+}
+
+// manifestServer is server with key which identifies it in state file
+type manifestServer struct {
+	Key    string `yaml:"key,omitempty"`
+	Server `yaml:",inline"`
+}
+
+// UnmarshalYAML accepts rules also in compact format of --rulesin flag (protocol;port;host;group;...)
+func (fw *manifestFirewall) UnmarshalYAML(node *yaml.Node) error {
+	for _, field := range []string{"rulesIn", "rulesOut"} {
+		if err := expandCompactItems(node, field, parseFirewallRules); err != nil {
+			return err
+		}
+	}
+	type plain manifestFirewall
+	if err := checkKnownFields(node, reflect.TypeOf(plain{})); err != nil {
+		return err
+	}
+	return node.Decode((*plain)(fw))
+}
+
+// UnmarshalYAML accepts listeners also in compact format of --listeners flag (listenPort;protocol;forwardPort;forwardHost;description)
+func (s *manifestServer) UnmarshalYAML(node *yaml.Node) error {
+	if err := expandCompactItems(node, "listeners", parseListeners); err != nil {
+		return err
+	}
+	type plain manifestServer
+	if err := checkKnownFields(node, reflect.TypeOf(plain{})); err != nil {
+		return err
+	}
+	return node.Decode((*plain)(s))
+}
+
+// checkKnownFields reports mapping keys in node which are not fields of type t (recursively),
+// node.Decode does not inherit KnownFields of decoder, so custom unmarshalers check their nodes by it
+func checkKnownFields(node *yaml.Node, t reflect.Type) error {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch {
+	case t.Kind() == reflect.Slice && node.Kind == yaml.SequenceNode:
+		for _, item := range node.Content {
+			if err := checkKnownFields(item, t.Elem()); err != nil {
+				return err
+			}
+		}
+	case t.Kind() == reflect.Struct && node.Kind == yaml.MappingNode:
+		fields := map[string]reflect.Type{}
+		yamlFields(t, fields)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i]
+			// merge key is resolved by decoder
+			if key.Value == "<<" {
+				continue
+			}
+			ft, ok := fields[key.Value]
+			if !ok {
+				return fmt.Errorf("line %d: unknown field '%s'", key.Line, key.Value)
+			}
+			if err := checkKnownFields(node.Content[i+1], ft); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// yamlFields collects YAML keys of struct fields including inlined structs
+func yamlFields(t reflect.Type, fields map[string]reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+		switch {
+		case name == "-":
+		case strings.Contains(opts, "inline"):
+			yamlFields(f.Type, fields)
+		case name == "":
+			fields[strings.ToLower(f.Name)] = f.Type
+		default:
+			fields[name] = f.Type
+		}
+	}
+}
+
+// expandCompactItems replaces string items of sequence field in mapping node by objects parsed from them,
+// one string can contain more comma separated items like CLI flags
+func expandCompactItems[T any](node *yaml.Node, field string, parse func(string) ([]T, error)) error {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		seq := node.Content[i+1]
+		if node.Content[i].Value != field || seq.Kind != yaml.SequenceNode {
+			continue
+		}
+		var items []*yaml.Node
+		for _, item := range seq.Content {
+			if item.Kind != yaml.ScalarNode {
+				items = append(items, item)
+				continue
+			}
+			values, err := parse(item.Value)
+			if err != nil {
+				return fmt.Errorf("line %d: %s", item.Line, err)
+			}
+			for _, v := range values {
+				var n yaml.Node
+				if err := n.Encode(v); err != nil {
+					return err
+				}
+				items = append(items, &n)
+			}
+		}
+		seq.Content = items
+	}
+	return nil
+}
+
+// key returns key of firewall in state file, name is used if key is not set
+func (fw manifestFirewall) key() string {
+	if fw.Key != "" {
+		return fw.Key
+	}
+	return fw.Name
+}
+
+// key returns key of server in state file, name is used if key is not set
+func (s manifestServer) key() string {
+	if s.Key != "" {
+		return s.Key
+	}
+	return s.Name
+}
+
+func (m *Manifest) firewalls() []Firewall {
+	var ret []Firewall
+	for _, fw := range m.Firewalls {
+		ret = append(ret, fw.Firewall)
+	}
+	return ret
+}
+
+// manifest file extensions read from directories
+var manifestExtensions = []string{".yaml", ".yml", ".json"}
+
+func addManifestFlags(cmd *cobra.Command, usage string, required bool) {
+	usage += ", directory (YAML and JSON files are read recursively)\n" +
+		"	or - for standard input, can be used multiple times"
+	if required {
+		usage += " (required)"
+	}
+	cmd.Flags().StringSliceP("filename", "f", nil, usage)
+	cmd.Flags().StringSlice("var-file", nil, "YAML file with variables substituted in manifest (${NAME}), variables from file\n"+
+		"	take precedence over environment variables, can be used multiple times")
+	if required {
+		cmd.MarkFlagRequired("filename")
+	}
+}
+
+// loadManifestFromFlags loads manifest from files specified by --filename and --var-file flags
+func loadManifestFromFlags(cmd *cobra.Command) (*Manifest, *manifestLoader, error) {
+	filenames, _ := cmd.Flags().GetStringSlice("filename")
+	varFiles, _ := cmd.Flags().GetStringSlice("var-file")
+	l, err := newManifestLoader(varFiles)
+	if err != nil {
+		return nil, nil, err
+	}
+	m, err := l.load(filenames)
+	if err != nil {
+		return nil, nil, err
+	}
+	return m, l, nil
+}
+
+// manifestLoader reads manifest from files, directories and standard input,
+// all documents are merged into one manifest
+type manifestLoader struct {
+	vars map[string]string
+	// files read by loader, "-" is standard input
+	files []string
+	// true if any variable was substituted
+	substituted bool
+}
+
+func newManifestLoader(varFiles []string) (*manifestLoader, error) {
+	l := &manifestLoader{vars: map[string]string{}}
+	for _, f := range varFiles {
+		if err := l.loadVarFile(f); err != nil {
+			return nil, err
+		}
+	}
+	return l, nil
+}
+
+func (l *manifestLoader) readsStdin() bool {
+	for _, f := range l.files {
+		if f == "-" {
+			return true
+		}
+	}
+	return false
+}
+
+// load reads and validates manifest from paths
+func (l *manifestLoader) load(paths []string) (*Manifest, error) {
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no manifest file specified")
+	}
+	for _, path := range paths {
+		files, err := manifestFiles(path)
+		if err != nil {
+			return nil, err
+		}
+		l.files = append(l.files, files...)
+	}
+	var m Manifest
+	for _, f := range l.files {
+		if err := l.loadFile(f, &m); err != nil {
+			return nil, err
+		}
+	}
+	if err := m.validate(); err != nil {
+		if len(l.files) == 1 {
+			return nil, fmt.Errorf("%s: %s", displayPath(l.files[0]), err)
+		}
+		return nil, err
+	}
+	return &m, nil
+}
+
+// manifestFiles returns path or manifest files from directory and its subdirectories in lexical order,
+// hidden files and directories are skipped
+func manifestFiles(path string) ([]string, error) {
+	if path == "-" {
+		return []string{path}, nil
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+	var ret []string
+	err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p != path && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		for _, ext := range manifestExtensions {
+			if strings.ToLower(filepath.Ext(p)) == ext {
+				ret = append(ret, p)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(ret) == 0 {
+		return nil, fmt.Errorf("%s: no manifest files (%s) found", path, strings.Join(manifestExtensions, ", "))
+	}
+	return ret, nil
+}
+
+func displayPath(path string) string {
+	if path == "-" {
+		return "(stdin)"
+	}
+	return path
+}
+
+// loadFile reads all YAML documents from file and appends them to manifest
+func (l *manifestLoader) loadFile(path string, m *Manifest) error {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return err
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	for doc := 1; ; doc++ {
+		var node yaml.Node
+		err := dec.Decode(&node)
+		if err == io.EOF {
+			return nil
+		}
+		if err == nil {
+			if err := l.substitute(displayPath(path), &node); err != nil {
+				return err
+			}
+		}
+		var part Manifest
+		if err == nil && len(node.Content) > 0 {
+			// misspelled key would be silently dropped and apply could remove what it declares
+			err = checkKnownFields(node.Content[0], reflect.TypeOf(part))
+		}
+		if err == nil {
+			err = node.Decode(&part)
+		}
+		if err != nil {
+			if doc > 1 {
+				return fmt.Errorf("%s (document %d): %s", displayPath(path), doc, err)
+			}
+			return fmt.Errorf("%s: %s", displayPath(path), err)
+		}
+		m.Firewalls = append(m.Firewalls, part.Firewalls...)
+		m.Servers = append(m.Servers, part.Servers...)
+		m.Protected = append(m.Protected, part.Protected...)
+	}
+}
+
+func (m *Manifest) validate() error {
+	fwNames := map[string]bool{}
+	fwKeys := map[string]bool{}
+	for i, fw := range m.Firewalls {
+		if fw.Name == "" {
+			return fmt.Errorf("firewall #%d has no name", i+1)
+		}
+		if fwNames[fw.Name] {
+			return fmt.Errorf("duplicate firewall name '%s'", fw.Name)
+		}
+		fwNames[fw.Name] = true
+		if fwKeys[fw.key()] {
+			return fmt.Errorf("duplicate firewall key '%s'", fw.key())
+		}
+		fwKeys[fw.key()] = true
+		for _, r := range append(append([]FirewallRule{}, fw.RulesIn...), fw.RulesOut...) {
+			if err := validateFirewallRule(r); err != nil {
+				return fmt.Errorf("firewall '%s': %s", fw.Name, err)
+			}
+		}
+	}
+	srvNames := map[string]bool{}
+	srvKeys := map[string]bool{}
+	for i, s := range m.Servers {
+		if s.Name == "" {
+			return fmt.Errorf("server #%d has no name", i+1)
+		}
+		if srvNames[s.Name] {
+			return fmt.Errorf("duplicate server name '%s'", s.Name)
+		}
+		srvNames[s.Name] = true
+		if srvKeys[s.key()] {
+			return fmt.Errorf("duplicate server key '%s'", s.key())
+		}
+		srvKeys[s.key()] = true
+		if s.Firewall.Id == "" && s.Firewall.Name == "" {
+			return fmt.Errorf("server '%s' has no firewall, either firewall id or firewall name must be specified", s.Name)
+		}
+		for _, l := range s.Listeners {
+			if err := validateListener(l); err != nil {
+				return fmt.Errorf("server '%s': %s", s.Name, err)
+			}
+		}
+		if err := validateUpdateHour(s.OSUpdatePolicy.UpdateHour); err != nil {
+			return fmt.Errorf("server '%s': %s", s.Name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// misspelled keys have to fail, otherwise apply would write objects without the misspelled section
+func TestLoadManifestUnknownFields(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest string
+		wantErr  string
+	}{
+		{
+			name: "valid",
+			manifest: `firewalls:
+  - name: web
+    rulesIn:
+      - tcp;443;any
+      - protocol: tcp
+        port: "22"
+        host: group
+        groups:
+          - name: admins
+servers:
+  - name: web-1
+    firewall:
+      name: web
+    listeners:
+      - 80;tcp;8080;localhost
+    osUpdatePolicy:
+      enabled: true
+`,
+		},
+		{name: "top level", manifest: "server:\n  - name: web-1\n", wantErr: "line 1: unknown field 'server'"},
+		{name: "firewall field", manifest: "firewalls:\n  - name: web\n    rulesin:\n      - tcp;443;any\n", wantErr: "line 3: unknown field 'rulesin'"},
+		{name: "rule field", manifest: "firewalls:\n  - name: web\n    rulesIn:\n      - protocol: tcp\n        prot: \"22\"\n        host: any\n", wantErr: "line 5: unknown field 'prot'"},
+		{name: "group field", manifest: "firewalls:\n  - name: web\n    rulesIn:\n      - protocol: tcp\n        port: \"22\"\n        host: group\n        groups:\n          - nme: admins\n", wantErr: "line 8: unknown field 'nme'"},
+		{name: "server field", manifest: "servers:\n  - name: web-1\n    firewall:\n      name: web\n    listner:\n      - 80;tcp;8080;localhost\n", wantErr: "line 5: unknown field 'listner'"},
+		{name: "nested server field", manifest: "servers:\n  - name: web-1\n    firewall:\n      nam: web\n", wantErr: "line 4: unknown field 'nam'"},
+		{name: "policy field", manifest: "servers:\n  - name: web-1\n    firewall:\n      name: web\n    osUpdatePolicy:\n      updatehour: 3\n", wantErr: "line 6: unknown field 'updatehour'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "manifest.yaml")
+			if err := os.WriteFile(path, []byte(tt.manifest), 0644); err != nil {
+				t.Fatal(err)
+			}
+			l, err := newManifestLoader(nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = l.load([]string{path})
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("got error %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
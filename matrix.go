@@ -0,0 +1,300 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"html"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var matrixFormats = []string{"csv", "markdown", "html", "dot"}
+
+// portSet is set of allowed port ranges by protocol (tcp, udp, icmp), icmp has range 0-0
+type portSet map[string][][2]int
+
+var matrixProtocols = []string{"tcp", "udp", "icmp"}
+
+// add adds port range of protocol, ranges are kept sorted and merged
+func (s portSet) add(protocol string, lo int, hi int) {
+	ranges := append(s[protocol], [2]int{lo, hi})
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i][0] < ranges[j][0] })
+	merged := [][2]int{ranges[0]}
+	for _, r := range ranges[1:] {
+		last := &merged[len(merged)-1]
+		if r[0] <= last[1]+1 {
+			if r[1] > last[1] {
+				last[1] = r[1]
+			}
+			continue
+		}
+		merged = append(merged, r)
+	}
+	s[protocol] = merged
+}
+
+// addRule adds ports and protocols allowed by rule
+func (s portSet) addRule(r FirewallRule) {
+	protocols := []string{r.Protocol}
+	if r.Protocol == "any" {
+		protocols = matrixProtocols
+	}
+	lo, hi, ok := portRange(r.Port)
+	if !ok || lo > hi {
+		return
+	}
+	for _, p := range protocols {
+		if p == "icmp" {
+			s.add(p, 0, 0)
+		} else {
+			s.add(p, lo, hi)
+		}
+	}
+}
+
+func (s portSet) union(other portSet) {
+	for p, ranges := range other {
+		for _, r := range ranges {
+			s.add(p, r[0], r[1])
+		}
+	}
+}
+
+func intersectPortSets(a portSet, b portSet) portSet {
+	ret := portSet{}
+	for p, ar := range a {
+		for _, x := range ar {
+			for _, y := range b[p] {
+				lo, hi := x[0], x[1]
+				if y[0] > lo {
+					lo = y[0]
+				}
+				if y[1] < hi {
+					hi = y[1]
+				}
+				if lo <= hi {
+					ret.add(p, lo, hi)
+				}
+			}
+		}
+	}
+	return ret
+}
+
+// String renders set like "tcp:22,8000-8999 udp:53 icmp", all ports and protocols are rendered as "any"
+func (s portSet) String() string {
+	full := len(s["icmp"]) > 0
+	for _, p := range []string{"tcp", "udp"} {
+		full = full && len(s[p]) == 1 && s[p][0] == [2]int{1, 65535}
+	}
+	if full {
+		return "any"
+	}
+	var parts []string
+	for _, p := range matrixProtocols {
+		ranges := s[p]
+		if len(ranges) == 0 {
+			continue
+		}
+		if p == "icmp" {
+			parts = append(parts, "icmp")
+			continue
+		}
+		var ports []string
+		for _, r := range ranges {
+			switch {
+			case r[0] == 1 && r[1] == 65535:
+				ports = append(ports, "any")
+			case r[0] == r[1]:
+				ports = append(ports, strconv.Itoa(r[0]))
+			default:
+				ports = append(ports, fmt.Sprintf("%d-%d", r[0], r[1]))
+			}
+		}
+		parts = append(parts, p+":"+strings.Join(ports, ","))
+	}
+	return strings.Join(parts, " ")
+}
+
+// ruleMatchesPeer checks if rule applies to peer server (any host or peer is member of any rule group)
+func ruleMatchesPeer(r FirewallRule, peer Server) bool {
+	if r.Host == "any" {
+		return true
+	}
+	for _, g := range r.Groups {
+		if containsGroup(peer.Groups, g) {
+			return true
+		}
+	}
+	return false
+}
+
+func rulesPortSet(rules []FirewallRule, peer Server) portSet {
+	ret := portSet{}
+	for _, r := range rules {
+		if ruleMatchesPeer(r, peer) {
+			ret.addRule(r)
+		}
+	}
+	return ret
+}
+
+// allowedPorts returns traffic allowed from server to other server,
+// it must be allowed by output rules of source and input rules of destination
+func allowedPorts(from Server, fromFw Firewall, to Server, toFw Firewall) portSet {
+	return intersectPortSets(rulesPortSet(fromFw.RulesOut, to), rulesPortSet(toFw.RulesIn, from))
+}
+
+// reachMatrix contains allowed traffic between rows (sources) and columns (destinations)
+type reachMatrix struct {
+	Names []string
+	Cells map[string]map[string]portSet
+	// value of cell from item to itself if it is empty
+	Self string
+}
+
+func (m *reachMatrix) cell(from string, to string) portSet {
+	if m.Cells[from] == nil {
+		m.Cells[from] = map[string]portSet{}
+	}
+	if m.Cells[from][to] == nil {
+		m.Cells[from][to] = portSet{}
+	}
+	return m.Cells[from][to]
+}
+
+// serverMatrix computes allowed traffic between all servers
+func (t *tenantSnapshot) serverMatrix() (*reachMatrix, error) {
+	m := &reachMatrix{Cells: map[string]map[string]portSet{}, Self: "-"}
+	firewalls := map[string]Firewall{}
+	for _, s := range t.servers {
+		fw, err := t.serverFirewall(s)
+		if err != nil {
+			return nil, err
+		}
+		firewalls[s.Name] = fw
+		m.Names = append(m.Names, s.Name)
+	}
+	sort.Strings(m.Names)
+	for _, from := range t.servers {
+		for _, to := range t.servers {
+			if from.Name == to.Name {
+				continue
+			}
+			m.cell(from.Name, to.Name).union(allowedPorts(from, firewalls[from.Name], to, firewalls[to.Name]))
+		}
+	}
+	return m, nil
+}
+
+// groupMatrix computes allowed traffic between groups, traffic is allowed from group to group
+// if it is allowed from any member server of source group to any member server of destination group
+func (t *tenantSnapshot) groupMatrix(groups []Group) (*reachMatrix, error) {
+	servers, err := t.serverMatrix()
+	if err != nil {
+		return nil, err
+	}
+	m := &reachMatrix{Cells: map[string]map[string]portSet{}}
+	for _, g := range groups {
+		m.Names = append(m.Names, g.Name)
+	}
+	sort.Strings(m.Names)
+	for _, from := range t.servers {
+		for _, to := range t.servers {
+			if from.Name == to.Name {
+				continue
+			}
+			allowed := servers.cell(from.Name, to.Name)
+			for _, fg := range groups {
+				if !containsGroup(from.Groups, Group{Id: fg.Id}) {
+					continue
+				}
+				for _, tg := range groups {
+					if containsGroup(to.Groups, Group{Id: tg.Id}) {
+						m.cell(fg.Name, tg.Name).union(allowed)
+					}
+				}
+			}
+		}
+	}
+	return m, nil
+}
+
+// rows returns header and rows of matrix, sources are rows, destinations are columns
+func (m *reachMatrix) rows(corner string) ([]string, [][]string) {
+	header := append([]string{corner}, m.Names...)
+	var rows [][]string
+	for _, from := range m.Names {
+		row := []string{from}
+		for _, to := range m.Names {
+			value := ""
+			if s := m.Cells[from][to]; s != nil {
+				value = s.String()
+			}
+			if from == to && value == "" {
+				value = m.Self
+			}
+			row = append(row, value)
+		}
+		rows = append(rows, row)
+	}
+	return header, rows
+}
+
+func printMatrix(w io.Writer, format string, m *reachMatrix, corner string) error {
+	header, rows := m.rows(corner)
+	switch format {
+	case "csv":
+		cw := csv.NewWriter(w)
+		cw.Write(header)
+		cw.WriteAll(rows)
+		return cw.Error()
+	case "markdown":
+		escape := func(cells []string) string {
+			var ret []string
+			for _, c := range cells {
+				ret = append(ret, strings.ReplaceAll(c, "|", "\\|"))
+			}
+			return "| " + strings.Join(ret, " | ") + " |"
+		}
+		fmt.Fprintln(w, escape(header))
+		fmt.Fprintln(w, "|"+strings.Repeat("---|", len(header)))
+		for _, row := range rows {
+			fmt.Fprintln(w, escape(row))
+		}
+		return nil
+	case "html":
+		fmt.Fprintln(w, "<table>")
+		fmt.Fprint(w, "  <tr>")
+		for _, h := range header {
+			fmt.Fprintf(w, "<th>%s</th>", html.EscapeString(h))
+		}
+		fmt.Fprintln(w, "</tr>")
+		for _, row := range rows {
+			fmt.Fprintf(w, "  <tr><th>%s</th>", html.EscapeString(row[0]))
+			for _, c := range row[1:] {
+				fmt.Fprintf(w, "<td>%s</td>", html.EscapeString(c))
+			}
+			fmt.Fprintln(w, "</tr>")
+		}
+		fmt.Fprintln(w, "</table>")
+		return nil
+	case "dot":
+		fmt.Fprintln(w, "digraph matrix {")
+		for _, name := range m.Names {
+			fmt.Fprintf(w, "  %q;\n", name)
+		}
+		for _, from := range m.Names {
+			for _, to := range m.Names {
+				if s := m.Cells[from][to]; from != to && len(s) > 0 {
+					fmt.Fprintf(w, "  %q -> %q [label=%q];\n", from, to, s.String())
+				}
+			}
+		}
+		fmt.Fprintln(w, "}")
+		return nil
+	}
+	return fmt.Errorf("unknown format '%s', supported formats are %s", format, strings.Join(matrixFormats, ", "))
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPortSetAdd(t *testing.T) {
+	tests := []struct {
+		name   string
+		ranges [][2]int
+		want   [][2]int
+	}{
+		{name: "single port", ranges: [][2]int{{22, 22}}, want: [][2]int{{22, 22}}},
+		{name: "sorted", ranges: [][2]int{{443, 443}, {22, 22}, {80, 80}}, want: [][2]int{{22, 22}, {80, 80}, {443, 443}}},
+		{name: "overlapping", ranges: [][2]int{{8000, 8100}, {8050, 8200}}, want: [][2]int{{8000, 8200}}},
+		{name: "adjacent", ranges: [][2]int{{22, 22}, {23, 25}}, want: [][2]int{{22, 25}}},
+		{name: "contained", ranges: [][2]int{{1, 1000}, {22, 22}}, want: [][2]int{{1, 1000}}},
+		{name: "gap is kept", ranges: [][2]int{{22, 22}, {24, 24}}, want: [][2]int{{22, 22}, {24, 24}}},
+		{name: "merged through new range", ranges: [][2]int{{10, 20}, {30, 40}, {15, 35}}, want: [][2]int{{10, 40}}},
+		{name: "duplicate", ranges: [][2]int{{53, 53}, {53, 53}}, want: [][2]int{{53, 53}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := portSet{}
+			for _, r := range tt.ranges {
+				s.add("tcp", r[0], r[1])
+			}
+			if !reflect.DeepEqual(s["tcp"], tt.want) {
+				t.Errorf("got %v, want %v", s["tcp"], tt.want)
+			}
+		})
+	}
+}
+
+func TestPortSetString(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules []FirewallRule
+		want  string
+	}{
+		{name: "empty"},
+		{name: "any protocol and port", rules: []FirewallRule{{Protocol: "any", Port: "any"}}, want: "any"},
+		{name: "all ports of all protocols", rules: []FirewallRule{{Protocol: "tcp", Port: "any"}, {Protocol: "udp", Port: "1-65535"}, {Protocol: "icmp", Port: "any"}}, want: "any"},
+		{name: "all ports without icmp", rules: []FirewallRule{{Protocol: "tcp", Port: "any"}, {Protocol: "udp", Port: "any"}}, want: "tcp:any udp:any"},
+		{name: "ports and ranges", rules: []FirewallRule{{Protocol: "tcp", Port: "8000-8999"}, {Protocol: "tcp", Port: "22"}, {Protocol: "udp", Port: "53"}}, want: "tcp:22,8000-8999 udp:53"},
+		{name: "icmp", rules: []FirewallRule{{Protocol: "icmp", Port: "any"}, {Protocol: "tcp", Port: "443"}}, want: "tcp:443 icmp"},
+		// any protocol with single port allows icmp too
+		{name: "any protocol with port", rules: []FirewallRule{{Protocol: "any", Port: "53"}}, want: "tcp:53 udp:53 icmp"},
+		{name: "invalid port is ignored", rules: []FirewallRule{{Protocol: "tcp", Port: "x"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := portSet{}
+			for _, r := range tt.rules {
+				s.addRule(r)
+			}
+			if got := s.String(); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIntersectPortSets(t *testing.T) {
+	set := func(rules ...FirewallRule) portSet {
+		s := portSet{}
+		for _, r := range rules {
+			s.addRule(r)
+		}
+		return s
+	}
+	tests := []struct {
+		name string
+		a    portSet
+		b    portSet
+		want string
+	}{
+		{name: "any and port", a: set(FirewallRule{Protocol: "any", Port: "any"}), b: set(FirewallRule{Protocol: "tcp", Port: "22"}), want: "tcp:22"},
+		{name: "overlapping ranges", a: set(FirewallRule{Protocol: "tcp", Port: "1000-2000"}), b: set(FirewallRule{Protocol: "tcp", Port: "1500-2500"}), want: "tcp:1500-2000"},
+		{name: "range and ports", a: set(FirewallRule{Protocol: "tcp", Port: "20-30"}), b: set(FirewallRule{Protocol: "tcp", Port: "22"}, FirewallRule{Protocol: "tcp", Port: "25"}, FirewallRule{Protocol: "tcp", Port: "80"}), want: "tcp:22,25"},
+		{name: "other protocol", a: set(FirewallRule{Protocol: "tcp", Port: "53"}), b: set(FirewallRule{Protocol: "udp", Port: "53"})},
+		{name: "icmp", a: set(FirewallRule{Protocol: "any", Port: "any"}), b: set(FirewallRule{Protocol: "icmp", Port: "any"}), want: "icmp"},
+		{name: "any and any", a: set(FirewallRule{Protocol: "any", Port: "any"}), b: set(FirewallRule{Protocol: "any", Port: "any"}), want: "any"},
+		{name: "empty", a: portSet{}, b: set(FirewallRule{Protocol: "any", Port: "any"})},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := intersectPortSets(tt.a, tt.b).String(); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func testSnapshot() *tenantSnapshot {
+	return &tenantSnapshot{
+		servers: []Server{
+			{Name: "web-1", Firewall: Firewall{Id: "fw:web"}, Groups: []Group{testOps}},
+			{Name: "web-2", Firewall: Firewall{Id: "fw:web"}, Groups: []Group{testOps}},
+			{Name: "db-1", Firewall: Firewall{Id: "fw:db"}, Groups: []Group{testAdmins}},
+			{Name: "jump-1", Firewall: Firewall{Id: "fw:jump"}, Groups: []Group{testAdmins, testOps}},
+		},
+		firewalls: []Firewall{
+			{Id: "fw:web", Name: "web",
+				RulesOut: []FirewallRule{{Protocol: "any", Port: "any", Host: "any"}},
+				RulesIn: []FirewallRule{
+					{Protocol: "tcp", Port: "443", Host: "any"},
+					{Protocol: "icmp", Port: "any", Host: "any"},
+					{Protocol: "udp", Port: "53", Host: "group", Groups: []Group{testAdmins}},
+				}},
+			{Id: "fw:db", Name: "db",
+				RulesOut: []FirewallRule{{Protocol: "udp", Port: "53", Host: "any"}},
+				RulesIn:  []FirewallRule{{Protocol: "tcp", Port: "5432", Host: "group", Groups: []Group{{Id: "g:2"}}}}},
+			{Id: "fw:jump", Name: "jump",
+				RulesOut: []FirewallRule{{Protocol: "tcp", Port: "22", Host: "any"}},
+				RulesIn:  []FirewallRule{{Protocol: "tcp", Port: "22", Host: "group", Groups: []Group{testAdmins, testOps}}}},
+		},
+	}
+}
+
+func TestServerMatrix(t *testing.T) {
+	m, err := testSnapshot().serverMatrix()
+	if err != nil {
+		t.Fatal(err)
+	}
+	header, rows := m.rows("from/to")
+	if want := []string{"from/to", "db-1", "jump-1", "web-1", "web-2"}; !reflect.DeepEqual(header, want) {
+		t.Errorf("got header %v, want %v", header, want)
+	}
+	want := [][]string{
+		// db-1 sends only udp:53, web accepts it from admins
+		{"db-1", "-", "", "udp:53", "udp:53"},
+		// jump-1 sends only tcp:22, which is accepted only by jump firewall
+		{"jump-1", "", "-", "", ""},
+		// web-1 is in ops group, so it reaches db on 5432
+		{"web-1", "tcp:5432", "tcp:22", "-", "tcp:443 icmp"},
+		{"web-2", "tcp:5432", "tcp:22", "tcp:443 icmp", "-"},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("got rows\n%v\nwant\n%v", rows, want)
+	}
+}
+
+func TestGroupMatrix(t *testing.T) {
+	m, err := testSnapshot().groupMatrix([]Group{testOps, testAdmins})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, rows := m.rows("from/to")
+	// traffic of all member servers is merged, servers are not compared with themselves
+	want := [][]string{
+		{"admins", "", "udp:53"},
+		{"ops", "tcp:22,5432", "tcp:22,443 icmp"},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("got rows\n%v\nwant\n%v", rows, want)
+	}
+
+	if _, err := (&tenantSnapshot{servers: []Server{{Name: "web-1", Firewall: Firewall{Id: "fw:missing"}}}}).groupMatrix(nil); err == nil {
+		t.Error("expected error for missing firewall")
+	}
+}
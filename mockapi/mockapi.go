@@ -0,0 +1,452 @@
+// Package mockapi is in-memory fake of shieldoo CLI API (/cliapi) for tests and local development.
+//
+// Requests are authenticated the same way as by real server - AuthToken header must contain
+// JWT token signed by API key (HS512) which is not expired and (if instance is set) has
+// shieldoo.instance claim equal to instance.
+//
+// Example:
+//
+//	api := mockapi.New("apikey", "")
+//	api.AddGroup(client.Group{Name: "admins"})
+//	srv := httptest.NewServer(api)
+//	defer srv.Close()
+//	c := client.New(srv.URL, "apikey")
+package mockapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/shieldoo/shieldoo-cli/client"
+)
+
+// Server is HTTP handler of fake API, objects are stored in memory
+type Server struct {
+	apiKey   string
+	instance string
+
+	mu        sync.Mutex
+	seq       int
+	servers   []client.Server
+	firewalls []client.Firewall
+	groups    []client.Group
+}
+
+// New creates fake API accepting tokens signed by apiKey,
+// if instance is empty than instance claim of token is not checked
+func New(apiKey string, instance string) *Server {
+	return &Server{apiKey: apiKey, instance: instance}
+}
+
+// httpError is returned by handlers to respond with other status than 200
+type httpError struct {
+	status  int
+	message string
+}
+
+func (e *httpError) Error() string {
+	return e.message
+}
+
+func errorf(status int, format string, args ...interface{}) error {
+	return &httpError{status: status, message: fmt.Sprintf(format, args...)}
+}
+
+func (s *Server) nextId(entity string) string {
+	s.seq++
+	instance := s.instance
+	if instance == "" {
+		instance = "mock"
+	}
+	return fmt.Sprintf("%s:%s:%d", instance, entity, s.seq)
+}
+
+// authenticate validates AuthToken header
+func (s *Server) authenticate(r *http.Request) error {
+	token := r.Header.Get("AuthToken")
+	if token == "" {
+		return errorf(http.StatusUnauthorized, "missing AuthToken header")
+	}
+	claims := &client.JWTData{}
+	_, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		if t.Method != jwt.SigningMethodHS512 {
+			return nil, fmt.Errorf("unexpected signing method %s", t.Header["alg"])
+		}
+		return []byte(s.apiKey), nil
+	})
+	if err != nil {
+		return errorf(http.StatusUnauthorized, "invalid token: %s", err)
+	}
+	if claims.ExpiresAt == 0 {
+		return errorf(http.StatusUnauthorized, "invalid token: missing expiration")
+	}
+	if s.instance != "" && claims.ShieldooClaims["instance"] != s.instance {
+		return errorf(http.StatusUnauthorized, "invalid token: instance %q does not match", claims.ShieldooClaims["instance"])
+	}
+	return nil
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ret, err := s.handle(r)
+	if err != nil {
+		status := http.StatusInternalServerError
+		var herr *httpError
+		if errors.As(err, &herr) {
+			status = herr.status
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ret)
+}
+
+func (s *Server) handle(r *http.Request) (interface{}, error) {
+	if err := s.authenticate(r); err != nil {
+		return nil, err
+	}
+	path := strings.TrimPrefix(r.URL.EscapedPath(), "/cliapi/")
+	if path == r.URL.EscapedPath() {
+		return nil, errorf(http.StatusNotFound, "not found")
+	}
+	entity, id, _ := strings.Cut(path, "/")
+	id, err := url.QueryUnescape(id)
+	if err != nil {
+		return nil, errorf(http.StatusBadRequest, "invalid id")
+	}
+	name := r.URL.Query().Get("name")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch {
+	case r.Method == http.MethodGet && id == "":
+		return s.list(entity, name)
+	case r.Method == http.MethodGet:
+		return s.get(entity, id)
+	case r.Method == http.MethodPost && id == "":
+		return s.save(entity, "", r.Body)
+	case r.Method == http.MethodPut && id != "":
+		return s.save(entity, id, r.Body)
+	case r.Method == http.MethodDelete && id != "":
+		return struct{}{}, s.delete(entity, id)
+	}
+	return nil, errorf(http.StatusMethodNotAllowed, "method %s not allowed", r.Method)
+}
+
+func (s *Server) list(entity string, name string) (interface{}, error) {
+	switch entity {
+	case "servers":
+		ret := []client.Server{}
+		for _, x := range s.servers {
+			if name == "" || x.Name == name {
+				ret = append(ret, x)
+			}
+		}
+		return ret, nil
+	case "firewalls":
+		ret := []client.Firewall{}
+		for _, x := range s.firewalls {
+			if name == "" || x.Name == name {
+				ret = append(ret, x)
+			}
+		}
+		return ret, nil
+	case "groups":
+		ret := []client.Group{}
+		for _, x := range s.groups {
+			if name == "" || x.Name == name {
+				ret = append(ret, x)
+			}
+		}
+		return ret, nil
+	}
+	return nil, errorf(http.StatusNotFound, "unknown entity %s", entity)
+}
+
+func (s *Server) get(entity string, id string) (interface{}, error) {
+	switch entity {
+	case "servers":
+		if i := s.serverIndex(id); i >= 0 {
+			return s.servers[i], nil
+		}
+	case "firewalls":
+		if i := s.firewallIndex(id); i >= 0 {
+			return s.firewalls[i], nil
+		}
+	case "groups":
+		if i := s.groupIndex(id); i >= 0 {
+			return s.groups[i], nil
+		}
+	default:
+		return nil, errorf(http.StatusNotFound, "unknown entity %s", entity)
+	}
+	return nil, errorf(http.StatusNotFound, "%s %s not found", entity, id)
+}
+
+func (s *Server) save(entity string, id string, body io.Reader) (interface{}, error) {
+	switch entity {
+	case "servers":
+		var x client.Server
+		if err := json.NewDecoder(body).Decode(&x); err != nil {
+			return nil, errorf(http.StatusBadRequest, "invalid body: %s", err)
+		}
+		return s.saveServer(id, x)
+	case "firewalls":
+		var x client.Firewall
+		if err := json.NewDecoder(body).Decode(&x); err != nil {
+			return nil, errorf(http.StatusBadRequest, "invalid body: %s", err)
+		}
+		return s.saveFirewall(id, x)
+	case "groups":
+		var x client.Group
+		if err := json.NewDecoder(body).Decode(&x); err != nil {
+			return nil, errorf(http.StatusBadRequest, "invalid body: %s", err)
+		}
+		return s.saveGroup(id, x)
+	}
+	return nil, errorf(http.StatusNotFound, "unknown entity %s", entity)
+}
+
+func (s *Server) delete(entity string, id string) error {
+	switch entity {
+	case "servers":
+		i := s.serverIndex(id)
+		if i < 0 {
+			return errorf(http.StatusNotFound, "server %s not found", id)
+		}
+		s.servers = append(s.servers[:i], s.servers[i+1:]...)
+		return nil
+	case "firewalls":
+		i := s.firewallIndex(id)
+		if i < 0 {
+			return errorf(http.StatusNotFound, "firewall %s not found", id)
+		}
+		for _, srv := range s.servers {
+			if srv.Firewall.Id == id {
+				return errorf(http.StatusBadRequest, "firewall %s is used by server %s", id, srv.Name)
+			}
+		}
+		s.firewalls = append(s.firewalls[:i], s.firewalls[i+1:]...)
+		return nil
+	case "groups":
+		i := s.groupIndex(id)
+		if i < 0 {
+			return errorf(http.StatusNotFound, "group %s not found", id)
+		}
+		if usage := s.groupUsage(id); usage != "" {
+			return errorf(http.StatusBadRequest, "group %s is used by %s", id, usage)
+		}
+		s.groups = append(s.groups[:i], s.groups[i+1:]...)
+		return nil
+	}
+	return errorf(http.StatusNotFound, "unknown entity %s", entity)
+}
+
+func (s *Server) serverIndex(id string) int {
+	for i := range s.servers {
+		if s.servers[i].Id == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func (s *Server) firewallIndex(id string) int {
+	for i := range s.firewalls {
+		if s.firewalls[i].Id == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func (s *Server) groupIndex(id string) int {
+	for i := range s.groups {
+		if s.groups[i].Id == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// resolveGroups replaces group references (id, name or objectId) by stored groups
+func (s *Server) resolveGroups(refs []client.Group) ([]client.Group, error) {
+	var ret []client.Group
+	for _, ref := range refs {
+		found := false
+		for _, g := range s.groups {
+			if (ref.Id != "" && ref.Id == g.Id) ||
+				(ref.Id == "" && ref.Name != "" && ref.Name == g.Name) ||
+				(ref.Id == "" && ref.Name == "" && ref.ObjectId != "" && ref.ObjectId == g.ObjectId) {
+				ret = append(ret, g)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, errorf(http.StatusBadRequest, "group not found: %+v", ref)
+		}
+	}
+	return ret, nil
+}
+
+func (s *Server) saveFirewall(id string, fw client.Firewall) (interface{}, error) {
+	if fw.Name == "" {
+		return nil, errorf(http.StatusBadRequest, "firewall name is required")
+	}
+	for _, x := range s.firewalls {
+		if x.Name == fw.Name && x.Id != id {
+			return nil, errorf(http.StatusBadRequest, "firewall with name %s already exists", fw.Name)
+		}
+	}
+	for _, rules := range [][]client.FirewallRule{fw.RulesIn, fw.RulesOut} {
+		for i := range rules {
+			groups, err := s.resolveGroups(rules[i].Groups)
+			if err != nil {
+				return nil, err
+			}
+			rules[i].Groups = groups
+		}
+	}
+	if id == "" {
+		fw.Id = s.nextId("firewalls")
+		s.firewalls = append(s.firewalls, fw)
+		return fw, nil
+	}
+	i := s.firewallIndex(id)
+	if i < 0 {
+		return nil, errorf(http.StatusNotFound, "firewall %s not found", id)
+	}
+	fw.Id = id
+	s.firewalls[i] = fw
+	return fw, nil
+}
+
+func (s *Server) saveServer(id string, srv client.Server) (interface{}, error) {
+	if srv.Name == "" {
+		return nil, errorf(http.StatusBadRequest, "server name is required")
+	}
+	for _, x := range s.servers {
+		if x.Name == srv.Name && x.Id != id {
+			return nil, errorf(http.StatusBadRequest, "server with name %s already exists", srv.Name)
+		}
+	}
+	fi := s.firewallIndex(srv.Firewall.Id)
+	if fi < 0 {
+		return nil, errorf(http.StatusBadRequest, "firewall %s not found", srv.Firewall.Id)
+	}
+	srv.Firewall = s.firewalls[fi]
+	groups, err := s.resolveGroups(srv.Groups)
+	if err != nil {
+		return nil, err
+	}
+	srv.Groups = groups
+	if id == "" {
+		srv.Id = s.nextId("servers")
+	} else {
+		i := s.serverIndex(id)
+		if i < 0 {
+			return nil, errorf(http.StatusNotFound, "server %s not found", id)
+		}
+		srv.Id = id
+		if srv.IpAddress == "" {
+			srv.IpAddress = s.servers[i].IpAddress
+		}
+	}
+	if srv.IpAddress == "" {
+		srv.IpAddress = fmt.Sprintf("100.64.%d.%d", s.seq/250, s.seq%250+1)
+	}
+	srv.Configuration = "# shieldoo configuration of " + srv.Name
+	if id == "" {
+		s.servers = append(s.servers, srv)
+	} else {
+		s.servers[s.serverIndex(id)] = srv
+	}
+	return srv, nil
+}
+
+func (s *Server) saveGroup(id string, g client.Group) (interface{}, error) {
+	if g.Name == "" {
+		return nil, errorf(http.StatusBadRequest, "group name is required")
+	}
+	for _, x := range s.groups {
+		if x.Name == g.Name && x.Id != id {
+			return nil, errorf(http.StatusBadRequest, "group with name %s already exists", g.Name)
+		}
+	}
+	if id == "" {
+		g.Id = s.nextId("groups")
+		s.groups = append(s.groups, g)
+		return g, nil
+	}
+	i := s.groupIndex(id)
+	if i < 0 {
+		return nil, errorf(http.StatusNotFound, "group %s not found", id)
+	}
+	g.Id = id
+	s.groups[i] = g
+	// servers and firewalls contain copies of groups
+	update := func(groups []client.Group) {
+		for j := range groups {
+			if groups[j].Id == id {
+				groups[j] = g
+			}
+		}
+	}
+	for j := range s.servers {
+		update(s.servers[j].Groups)
+	}
+	for j := range s.firewalls {
+		for k := range s.firewalls[j].RulesIn {
+			update(s.firewalls[j].RulesIn[k].Groups)
+		}
+		for k := range s.firewalls[j].RulesOut {
+			update(s.firewalls[j].RulesOut[k].Groups)
+		}
+	}
+	return g, nil
+}
+
+// groupUsage returns description of first server or firewall which uses group, empty if group is not used
+func (s *Server) groupUsage(id string) string {
+	contains := func(groups []client.Group) bool {
+		for _, g := range groups {
+			if g.Id == id {
+				return true
+			}
+		}
+		return false
+	}
+	for _, srv := range s.servers {
+		if contains(srv.Groups) {
+			return "server " + srv.Name
+		}
+	}
+	for _, fw := range s.firewalls {
+		for _, r := range append(append([]client.FirewallRule{}, fw.RulesIn...), fw.RulesOut...) {
+			if contains(r.Groups) {
+				return "firewall " + fw.Name
+			}
+		}
+	}
+	return ""
+}
+
+// AddGroup stores group, ID is generated if it is empty
+func (s *Server) AddGroup(g client.Group) client.Group {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if g.Id == "" {
+		g.Id = s.nextId("groups")
+	}
+	s.groups = append(s.groups, g)
+	return g
+}
@@ -0,0 +1,113 @@
+package mockapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/shieldoo/shieldoo-cli/client"
+)
+
+func TestAuthentication(t *testing.T) {
+	tests := []struct {
+		name       string
+		instance   string
+		uri        string
+		apiKey     string
+		wantStatus int
+	}{
+		{name: "valid key", apiKey: "test-key"},
+		{name: "wrong key", apiKey: "other-key", wantStatus: http.StatusUnauthorized},
+		{name: "matching instance", instance: "127.0.0.1", apiKey: "test-key"},
+		{name: "wrong instance", instance: "demo.shieldoo.net", apiKey: "test-key", wantStatus: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(New("test-key", tt.instance))
+			defer srv.Close()
+			c := client.New(srv.URL, tt.apiKey)
+			_, err := c.ListServers(context.Background())
+			if tt.wantStatus == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				return
+			}
+			var apiErr *client.APIError
+			if !errors.As(err, &apiErr) || apiErr.StatusCode != tt.wantStatus {
+				t.Fatalf("got error %v, want status %d", err, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestMissingToken(t *testing.T) {
+	srv := httptest.NewServer(New("test-key", ""))
+	defer srv.Close()
+	resp, err := http.Get(srv.URL + "/cliapi/servers")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("got status %d, want %d", resp.StatusCode, http.StatusUnauthorized)
+	}
+}
+
+func TestNameFilter(t *testing.T) {
+	api := New("test-key", "")
+	for _, name := range []string{"admins", "admins-2", "web"} {
+		api.AddGroup(client.Group{Name: name})
+	}
+	srv := httptest.NewServer(api)
+	defer srv.Close()
+	c := client.New(srv.URL, "test-key")
+	ctx := context.Background()
+
+	tests := []struct {
+		name  string
+		group string
+		want  bool
+	}{
+		{"exact name", "admins", true},
+		{"prefix is not match", "admin", false},
+		{"other name", "web", true},
+		{"unknown", "db", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := c.GetGroupByName(ctx, tt.group)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (g != nil) != tt.want {
+				t.Fatalf("found = %v, want %v", g != nil, tt.want)
+			}
+			if g != nil && g.Name != tt.group {
+				t.Errorf("got group %q, want %q", g.Name, tt.group)
+			}
+		})
+	}
+	all, err := c.ListGroups(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 3 {
+		t.Errorf("listed %d groups, want 3", len(all))
+	}
+}
+
+func TestGetNotFound(t *testing.T) {
+	srv := httptest.NewServer(New("test-key", ""))
+	defer srv.Close()
+	c := client.New(srv.URL, "test-key")
+	_, err := c.GetServer(context.Background(), "mock:servers:1")
+	if !client.IsNotFound(err) {
+		t.Errorf("got error %v, want not found", err)
+	}
+	if err := c.DeleteFirewall(context.Background(), "mock:firewalls:1"); !client.IsNotFound(err) {
+		t.Errorf("got error %v, want not found", err)
+	}
+}
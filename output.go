@@ -0,0 +1,238 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+// output format set by global --output flag
+var outputFormat = "json"
+
+const outputFormats = "json, yaml, table, wide, name, jsonpath=..., jsonpath-file=..., go-template=... or go-template-file=..."
+
+// printOutput prints server, firewall or group (or list of them) in selected output format,
+// jsonpath and go-template formats are evaluated against JSON representation of data
+func printOutput(w io.Writer, v interface{}) error {
+	format, arg, _ := strings.Cut(outputFormat, "=")
+	switch format {
+	case "jsonpath", "jsonpath-file", "go-template", "go-template-file":
+		if arg == "" {
+			return fmt.Errorf("output format %s requires argument, for example -o %s='{.id}'", format, format)
+		}
+		if strings.HasSuffix(format, "-file") {
+			data, err := os.ReadFile(arg)
+			if err != nil {
+				return err
+			}
+			arg = string(data)
+		}
+		var ret string
+		var err error
+		if strings.HasPrefix(format, "jsonpath") {
+			ret, err = executeJsonpath(arg, v)
+		} else {
+			ret, err = executeGoTemplate(arg, v)
+		}
+		if err != nil {
+			return err
+		}
+		if !strings.HasSuffix(ret, "\n") {
+			ret += "\n"
+		}
+		fmt.Fprint(w, ret)
+		return nil
+	}
+	switch outputFormat {
+	case "json":
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(w, string(data))
+	case "yaml":
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(v); err != nil {
+			return err
+		}
+		return enc.Close()
+	case "name":
+		for _, name := range outputNames(v) {
+			fmt.Fprintln(w, name)
+		}
+	case "table", "wide":
+		header, rows := outputTable(v, outputFormat == "wide")
+		tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+		fmt.Fprintln(tw, strings.Join(header, "\t"))
+		for _, row := range rows {
+			fmt.Fprintln(tw, strings.Join(row, "\t"))
+		}
+		return tw.Flush()
+	default:
+		return fmt.Errorf("invalid output format: %s (expected %s)", outputFormat, outputFormats)
+	}
+	return nil
+}
+
+// executeGoTemplate executes Go template against JSON representation of data,
+// so field names are the same as in JSON output (for example {{.id}})
+func executeGoTemplate(tmpl string, data interface{}) (string, error) {
+	t, err := template.New("output").Option("missingkey=zero").Parse(tmpl)
+	if err != nil {
+		return "", err
+	}
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return "", err
+	}
+	var generic interface{}
+	if err := json.Unmarshal(raw, &generic); err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, generic); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func outputNames(v interface{}) []string {
+	var ret []string
+	switch x := v.(type) {
+	case Server:
+		ret = append(ret, x.Name)
+	case []Server:
+		for _, s := range x {
+			ret = append(ret, s.Name)
+		}
+	case Firewall:
+		ret = append(ret, x.Name)
+	case []Firewall:
+		for _, fw := range x {
+			ret = append(ret, fw.Name)
+		}
+	case Group:
+		ret = append(ret, x.Name)
+	case []Group:
+		for _, g := range x {
+			ret = append(ret, g.Name)
+		}
+	case []firewallUsages:
+		for _, u := range x {
+			for _, s := range u.Servers {
+				ret = append(ret, s.Name)
+			}
+		}
+	case []groupUsages:
+		for _, u := range x {
+			for _, s := range u.Servers {
+				ret = append(ret, s.Name)
+			}
+		}
+	}
+	return ret
+}
+
+func outputTable(v interface{}, wide bool) ([]string, [][]string) {
+	switch x := v.(type) {
+	case Server:
+		return serversTable([]Server{x}, wide)
+	case []Server:
+		return serversTable(x, wide)
+	case Firewall:
+		return firewallsTable([]Firewall{x}, wide)
+	case []Firewall:
+		return firewallsTable(x, wide)
+	case Group:
+		return groupsTable([]Group{x}, wide)
+	case []Group:
+		return groupsTable(x, wide)
+	case []firewallUsages:
+		return firewallUsagesTable(x)
+	case []groupUsages:
+		return groupUsagesTable(x)
+	}
+	return nil, nil
+}
+
+func groupNames(groups []Group) string {
+	var names []string
+	for _, g := range groups {
+		names = append(names, strings.TrimPrefix(formatGroup(g), "name="))
+	}
+	return strings.Join(names, ",")
+}
+
+func serversTable(servers []Server, wide bool) ([]string, [][]string) {
+	// firewall name is not always part of server detail
+	fwNames := map[string]string{}
+	for _, s := range servers {
+		if s.Firewall.Name == "" {
+			if fws, err := listFirewalls(); err == nil {
+				for _, fw := range fws {
+					fwNames[fw.Id] = fw.Name
+				}
+			}
+			break
+		}
+	}
+	header := []string{"NAME", "ID", "GROUPS", "LISTENERS", "FIREWALL"}
+	if wide {
+		header = append(header, "IP ADDRESS", "AUTOUPDATE", "OS AUTOUPDATE", "DESCRIPTION")
+	}
+	var rows [][]string
+	for _, s := range servers {
+		fw := s.Firewall.Name
+		if fw == "" {
+			fw = fwNames[s.Firewall.Id]
+		}
+		if fw == "" {
+			fw = s.Firewall.Id
+		}
+		row := []string{s.Name, s.Id, groupNames(s.Groups), strconv.Itoa(len(s.Listeners)), fw}
+		if wide {
+			row = append(row, s.IpAddress, strconv.FormatBool(s.Autoupdate), strconv.FormatBool(s.OSUpdatePolicy.Enabled), s.Description)
+		}
+		rows = append(rows, row)
+	}
+	return header, rows
+}
+
+func firewallsTable(fws []Firewall, wide bool) ([]string, [][]string) {
+	header := []string{"NAME", "ID", "RULES IN", "RULES OUT"}
+	var rows [][]string
+	for _, fw := range fws {
+		row := []string{fw.Name, fw.Id, strconv.Itoa(len(fw.RulesIn)), strconv.Itoa(len(fw.RulesOut))}
+		if wide {
+			row[2] = formatFirewallRules(fw.RulesIn)
+			row[3] = formatFirewallRules(fw.RulesOut)
+		}
+		rows = append(rows, row)
+	}
+	return header, rows
+}
+
+func groupsTable(groups []Group, wide bool) ([]string, [][]string) {
+	header := []string{"NAME", "ID", "OBJECT ID"}
+	if wide {
+		header = append(header, "DESCRIPTION")
+	}
+	var rows [][]string
+	for _, g := range groups {
+		row := []string{g.Name, g.Id, g.ObjectId}
+		if wide {
+			row = append(row, g.Description)
+		}
+		rows = append(rows, row)
+	}
+	return header, rows
+}
@@ -0,0 +1,434 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+)
+
+const (
+	actionNone   = "none"
+	actionCreate = "create"
+	actionUpdate = "update"
+	actionDelete = "delete"
+)
+
+// fieldChange is change of one field (or one item of list field) of resource,
+// Op is "+" for added value, "-" for removed value and "~" for modified value
+type fieldChange struct {
+	Op    string
+	Field string
+	Old   string
+	New   string
+}
+
+// resourceChange describes what will happen with one resource
+type resourceChange struct {
+	Kind    string
+	Name    string
+	Id      string
+	Action  string
+	Changes []fieldChange
+	// resource is tracked in state file, but it was deleted outside of manifest
+	Lost bool
+}
+
+type plan struct {
+	Resources []resourceChange
+}
+
+func (p *plan) add(rc resourceChange) {
+	p.Resources = append(p.Resources, rc)
+}
+
+func (p *plan) count(action string) int {
+	cnt := 0
+	for _, rc := range p.Resources {
+		if rc.Action == action {
+			cnt++
+		}
+	}
+	return cnt
+}
+
+func (p *plan) hasChanges() bool {
+	return p.count(actionCreate)+p.count(actionUpdate)+p.count(actionDelete) > 0
+}
+
+// withDefaultRulesOut returns firewall with default output rule if it has none,
+// the same way as firewall is sent to API
+func withDefaultRulesOut(fw Firewall) Firewall {
+	if len(fw.RulesOut) == 0 {
+		fw.RulesOut = []FirewallRule{defaultFirewallRuleOut}
+	}
+	return fw
+}
+
+// groupMatches checks if group reference (group with only one of id, name or objectId)
+// points to given group
+func groupMatches(ref Group, g Group) bool {
+	switch {
+	case ref.Id != "":
+		return ref.Id == g.Id
+	case ref.Name != "":
+		return ref.Name == g.Name
+	case ref.ObjectId != "":
+		return ref.ObjectId == g.ObjectId
+	}
+	return false
+}
+
+func containsGroup(groups []Group, ref Group) bool {
+	for _, g := range groups {
+		if groupMatches(ref, g) || groupMatches(g, ref) {
+			return true
+		}
+	}
+	return false
+}
+
+func sameGroups(a []Group, b []Group) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, g := range a {
+		if !containsGroup(b, g) {
+			return false
+		}
+	}
+	return true
+}
+
+func sameFirewallRule(a FirewallRule, b FirewallRule) bool {
+	return a.Protocol == b.Protocol && a.Port == b.Port && a.Host == b.Host && sameGroups(a.Groups, b.Groups)
+}
+
+func containsFirewallRule(rules []FirewallRule, rule FirewallRule) bool {
+	for _, r := range rules {
+		if sameFirewallRule(r, rule) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsListener(listeners []Listener, listener Listener) bool {
+	for _, l := range listeners {
+		if l == listener {
+			return true
+		}
+	}
+	return false
+}
+
+func diffScalar(changes []fieldChange, field string, old string, new string, create bool) []fieldChange {
+	if old == new {
+		return changes
+	}
+	if create {
+		return append(changes, fieldChange{Op: "+", Field: field, New: new})
+	}
+	return append(changes, fieldChange{Op: "~", Field: field, Old: old, New: new})
+}
+
+func diffRules(changes []fieldChange, field string, old []FirewallRule, new []FirewallRule) []fieldChange {
+	for _, r := range old {
+		if !containsFirewallRule(new, r) {
+			changes = append(changes, fieldChange{Op: "-", Field: field, Old: formatFirewallRule(r)})
+		}
+	}
+	for _, r := range new {
+		if !containsFirewallRule(old, r) {
+			changes = append(changes, fieldChange{Op: "+", Field: field, New: formatFirewallRule(r)})
+		}
+	}
+	return changes
+}
+
+func diffGroups(changes []fieldChange, field string, old []Group, new []Group) []fieldChange {
+	for _, g := range old {
+		if !containsGroup(new, g) {
+			changes = append(changes, fieldChange{Op: "-", Field: field, Old: formatGroup(g)})
+		}
+	}
+	for _, g := range new {
+		if !containsGroup(old, g) {
+			changes = append(changes, fieldChange{Op: "+", Field: field, New: formatGroup(g)})
+		}
+	}
+	return changes
+}
+
+func diffListeners(changes []fieldChange, field string, old []Listener, new []Listener) []fieldChange {
+	for _, l := range old {
+		if !containsListener(new, l) {
+			changes = append(changes, fieldChange{Op: "-", Field: field, Old: formatListener(l)})
+		}
+	}
+	for _, l := range new {
+		if !containsListener(old, l) {
+			changes = append(changes, fieldChange{Op: "+", Field: field, New: formatListener(l)})
+		}
+	}
+	return changes
+}
+
+func resourceAction(current bool, changes []fieldChange) string {
+	switch {
+	case !current:
+		return actionCreate
+	case len(changes) > 0:
+		return actionUpdate
+	}
+	return actionNone
+}
+
+// diffFirewall compares current firewall (nil if it does not exist) with desired one
+func diffFirewall(current *Firewall, desired Firewall) resourceChange {
+	desired = withDefaultRulesOut(desired)
+	rc := resourceChange{Kind: "firewall", Name: desired.Name}
+	old := Firewall{}
+	if current != nil {
+		old = *current
+		rc.Id = current.Id
+	}
+	// renamed resource is found by ID from state file
+	if current != nil {
+		rc.Changes = diffScalar(rc.Changes, "name", old.Name, desired.Name, false)
+	}
+	rc.Changes = diffRules(rc.Changes, "rulesIn", old.RulesIn, desired.RulesIn)
+	rc.Changes = diffRules(rc.Changes, "rulesOut", old.RulesOut, desired.RulesOut)
+	rc.Action = resourceAction(current != nil, rc.Changes)
+	return rc
+}
+
+// diffServer compares current server (nil if it does not exist) with desired one,
+// desiredFirewall is name or ID of desired firewall in the same form as is returned by currentFirewall
+func diffServer(current *Server, desired Server, currentFirewall string, desiredFirewall string) resourceChange {
+	rc := resourceChange{Kind: "server", Name: desired.Name}
+	old := Server{}
+	if current != nil {
+		old = *current
+		rc.Id = current.Id
+	}
+	create := current == nil
+	var ch []fieldChange
+	if current != nil {
+		ch = diffScalar(ch, "name", old.Name, desired.Name, false)
+	}
+	ch = diffScalar(ch, "firewall", currentFirewall, desiredFirewall, create)
+	ch = diffScalar(ch, "description", old.Description, desired.Description, create)
+	// IP address is assigned by server if not specified
+	if desired.IpAddress != "" {
+		ch = diffScalar(ch, "ipAddress", old.IpAddress, desired.IpAddress, create)
+	}
+	ch = diffGroups(ch, "groups", old.Groups, desired.Groups)
+	ch = diffListeners(ch, "listeners", old.Listeners, desired.Listeners)
+	ch = diffScalar(ch, "autoupdate", strconv.FormatBool(old.Autoupdate), strconv.FormatBool(desired.Autoupdate), create)
+	op, dp := old.OSUpdatePolicy, desired.OSUpdatePolicy
+	ch = diffScalar(ch, "osUpdatePolicy.enabled", strconv.FormatBool(op.Enabled), strconv.FormatBool(dp.Enabled), create)
+	ch = diffScalar(ch, "osUpdatePolicy.securityAutoupdateEnabled", strconv.FormatBool(op.SecurityAutoupdateEnabled), strconv.FormatBool(dp.SecurityAutoupdateEnabled), create)
+	ch = diffScalar(ch, "osUpdatePolicy.allAutoupdateEnabled", strconv.FormatBool(op.AllAutoupdateEnabled), strconv.FormatBool(dp.AllAutoupdateEnabled), create)
+	ch = diffScalar(ch, "osUpdatePolicy.restartAfterUpdate", strconv.FormatBool(op.RestartAfterUpdate), strconv.FormatBool(dp.RestartAfterUpdate), create)
+	ch = diffScalar(ch, "osUpdatePolicy.updateHour", strconv.Itoa(op.UpdateHour), strconv.Itoa(dp.UpdateHour), create)
+	rc.Changes = ch
+	rc.Action = resourceAction(current != nil, rc.Changes)
+	return rc
+}
+
+// diffGroup compares current group (nil if it does not exist) with desired one
+func diffGroup(current *Group, desired Group) resourceChange {
+	rc := resourceChange{Kind: "group", Name: desired.Name}
+	old := Group{}
+	if current != nil {
+		old = *current
+		rc.Id = current.Id
+	}
+	create := current == nil
+	rc.Changes = diffScalar(rc.Changes, "objectId", old.ObjectId, desired.ObjectId, create)
+	rc.Changes = diffScalar(rc.Changes, "description", old.Description, desired.Description, create)
+	rc.Action = resourceAction(current != nil, rc.Changes)
+	return rc
+}
+
+func planGroup(desired Group) (resourceChange, error) {
+	current, err := getGroup(desired.Name, "")
+	if err != nil {
+		return resourceChange{}, err
+	}
+	return diffGroup(current, desired), nil
+}
+
+func planFirewall(desired Firewall) (resourceChange, error) {
+	current, err := findFirewallByName(desired.Name)
+	if err != nil {
+		return resourceChange{}, err
+	}
+	return planFirewallChange(desired, current)
+}
+
+// planFirewallChange compares current firewall (nil if it does not exist) with desired one
+// with unresolved group references
+func planFirewallChange(desired Firewall, current *Firewall) (resourceChange, error) {
+	desired, err := resolveFirewallGroups(desired)
+	if err != nil {
+		return resourceChange{}, err
+	}
+	return diffFirewall(current, desired), nil
+}
+
+// planServer computes changes of server, manifestFirewalls contains IDs of firewalls declared
+// in manifest by name, ID is empty if firewall is not created yet, but will be created before server
+func planServer(desired Server, manifestFirewalls map[string]string) (resourceChange, error) {
+	current, err := findServerByName(desired.Name)
+	if err != nil {
+		return resourceChange{}, err
+	}
+	return planServerChange(desired, current, manifestFirewalls)
+}
+
+// planServerChange compares current server (nil if it does not exist) with desired one
+// with unresolved group references
+func planServerChange(desired Server, current *Server, manifestFirewalls map[string]string) (resourceChange, error) {
+	desired, err := resolveServerGroups(desired)
+	if err != nil {
+		return resourceChange{}, err
+	}
+	// firewall is compared by ID, name is displayed if it is known
+	currentFirewall := ""
+	if current != nil {
+		currentFirewall = current.Firewall.Id
+		if current.Firewall.Name != "" && current.Firewall.Name == desired.Firewall.Name {
+			currentFirewall = current.Firewall.Name
+		}
+	}
+	desiredFirewall := desired.Firewall.Id
+	if desiredFirewall == "" {
+		desiredFirewall = desired.Firewall.Name
+		fwId, declared := manifestFirewalls[desiredFirewall]
+		switch {
+		case currentFirewall == desiredFirewall:
+		case declared:
+			if fwId != "" {
+				desiredFirewall = fwId
+			}
+		default:
+			fw, err := findFirewallByName(desired.Firewall.Name)
+			if err != nil {
+				return resourceChange{}, err
+			}
+			if fw == nil {
+				return resourceChange{}, fmt.Errorf("no firewall found with name '%s'", desired.Firewall.Name)
+			}
+			desiredFirewall = fw.Id
+		}
+	}
+	return diffServer(current, desired, currentFirewall, desiredFirewall), nil
+}
+
+// planManifest computes changes needed to get tenant into state described by manifest,
+// resources not present in manifest are deleted only if prune options are set,
+// resources are found by IDs from state file if it is not nil
+func planManifest(m *Manifest, prune *pruneOptions, st *stateFile) (*plan, error) {
+	if err := resolveManifestGroups(m); err != nil {
+		return nil, err
+	}
+	l, err := newResourceLocator(st)
+	if err != nil {
+		return nil, err
+	}
+	p := &plan{}
+	manifestFirewalls := map[string]string{}
+	for _, fw := range m.Firewalls {
+		current, lost, err := l.firewall(fw)
+		if err != nil {
+			return nil, fmt.Errorf("firewall '%s': %s", fw.Name, err)
+		}
+		rc, err := planFirewallChange(fw.Firewall, current)
+		if err != nil {
+			return nil, fmt.Errorf("firewall '%s': %s", fw.Name, err)
+		}
+		rc.Lost = lost
+		manifestFirewalls[fw.Name] = rc.Id
+		p.add(rc)
+	}
+	for _, server := range m.Servers {
+		current, lost, err := l.server(server)
+		if err != nil {
+			return nil, fmt.Errorf("server '%s': %s", server.Name, err)
+		}
+		rc, err := planServerChange(server.Server, current, manifestFirewalls)
+		if err != nil {
+			return nil, fmt.Errorf("server '%s': %s", server.Name, err)
+		}
+		rc.Lost = lost
+		p.add(rc)
+	}
+	if prune != nil {
+		prune.Managed = l.managedIds(m)
+		deletions, err := planPrune(m, prune)
+		if err != nil {
+			return nil, err
+		}
+		// plan fails the same way as apply would
+		if err := checkMaxDeletions(deletions, prune); err != nil {
+			return nil, err
+		}
+		p.Resources = append(p.Resources, deletions...)
+	}
+	return p, nil
+}
+
+var planActionSymbols = map[string]string{
+	actionCreate: "+",
+	actionUpdate: "~",
+	actionDelete: "-",
+}
+
+var planActionTexts = map[string]string{
+	actionCreate: "will be created",
+	actionUpdate: "will be updated in-place",
+	actionDelete: "will be destroyed",
+}
+
+func planValue(v string) string {
+	if v == "" {
+		return `""`
+	}
+	return v
+}
+
+func printFieldChanges(w io.Writer, changes []fieldChange) {
+	for _, c := range changes {
+		switch c.Op {
+		case "+":
+			fmt.Fprintf(w, "      + %s: %s\n", c.Field, c.New)
+		case "-":
+			fmt.Fprintf(w, "      - %s: %s\n", c.Field, c.Old)
+		default:
+			fmt.Fprintf(w, "      ~ %s: %s -> %s\n", c.Field, planValue(c.Old), planValue(c.New))
+		}
+	}
+}
+
+// printPlan prints plan in Terraform-like format
+func printPlan(w io.Writer, p *plan) {
+	for _, rc := range p.Resources {
+		if rc.Action == actionNone {
+			continue
+		}
+		fmt.Fprintf(w, "  %s %s %q %s", planActionSymbols[rc.Action], rc.Kind, rc.Name, planActionTexts[rc.Action])
+		if rc.Lost {
+			fmt.Fprint(w, " (deleted outside of manifest)")
+		}
+		fmt.Fprintln(w)
+		printFieldChanges(w, rc.Changes)
+		fmt.Fprintln(w)
+	}
+	if !p.hasChanges() {
+		fmt.Fprintln(w, "No changes. Tenant is up-to-date.")
+		return
+	}
+	fmt.Fprintf(w, "Plan: %d to add, %d to change, %d to destroy.\n",
+		p.count(actionCreate), p.count(actionUpdate), p.count(actionDelete))
+}
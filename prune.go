@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// pruneOptions controls deletion of servers and firewalls which are not present in manifest
+type pruneOptions struct {
+	// names or name patterns (for example "prod-*") which are never deleted
+	Protected []string
+	// maximum number of deleted resources, 0 means no limit
+	MaxDeletions int
+	// skip interactive confirmation
+	Yes bool
+	// IDs of resources tracked in state file for manifest keys, they are kept even if they are renamed
+	Managed map[string]bool
+}
+
+func addPruneFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool("prune", false, "Delete servers and firewalls which are not present in manifest")
+	cmd.Flags().StringSlice("protect", nil, "Names or name patterns (example: prod-*) of servers and firewalls which are never deleted by prune,\n"+
+		"	names from manifest 'protected' list are added")
+	cmd.Flags().Int("max-deletions", 10, "Maximum number of resources deleted by prune, 0 means no limit")
+}
+
+// getPruneOptions returns prune options from command flags or nil if prune is not enabled
+func getPruneOptions(cmd *cobra.Command, m *Manifest) *pruneOptions {
+	prune, _ := cmd.Flags().GetBool("prune")
+	if !prune {
+		return nil
+	}
+	protect, _ := cmd.Flags().GetStringSlice("protect")
+	maxDeletions, _ := cmd.Flags().GetInt("max-deletions")
+	opts := &pruneOptions{
+		Protected:    append(protect, m.Protected...),
+		MaxDeletions: maxDeletions,
+	}
+	if cmd.Flags().Lookup("yes") != nil {
+		opts.Yes, _ = cmd.Flags().GetBool("yes")
+	}
+	return opts
+}
+
+func (o *pruneOptions) isProtected(name string) bool {
+	for _, p := range o.Protected {
+		if p == name {
+			return true
+		}
+		if ok, _ := path.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// planPrune finds servers and firewalls which are not in manifest and should be deleted,
+// firewalls still used by servers which are kept are not deleted
+func planPrune(m *Manifest, opts *pruneOptions) ([]resourceChange, error) {
+	var ret []resourceChange
+	declaredServers := map[string]bool{}
+	for _, s := range m.Servers {
+		declaredServers[s.Name] = true
+	}
+	declaredFirewalls := map[string]bool{}
+	for _, fw := range m.Firewalls {
+		declaredFirewalls[fw.Name] = true
+	}
+
+	servers, err := listServers()
+	if err != nil {
+		return nil, err
+	}
+	// firewalls referenced by servers which stay in tenant
+	usedFirewalls := map[string]bool{}
+	for _, s := range m.Servers {
+		usedFirewalls[s.Firewall.Id] = true
+		usedFirewalls[s.Firewall.Name] = true
+	}
+	for _, s := range servers {
+		if declaredServers[s.Name] || opts.Managed[s.Id] {
+			continue
+		}
+		if opts.isProtected(s.Name) {
+			usedFirewalls[s.Firewall.Id] = true
+			continue
+		}
+		ret = append(ret, resourceChange{Kind: "server", Name: s.Name, Id: s.Id, Action: actionDelete})
+	}
+
+	firewalls, err := listFirewalls()
+	if err != nil {
+		return nil, err
+	}
+	for _, fw := range firewalls {
+		if declaredFirewalls[fw.Name] || opts.Managed[fw.Id] || opts.isProtected(fw.Name) {
+			continue
+		}
+		if usedFirewalls[fw.Id] || usedFirewalls[fw.Name] {
+			continue
+		}
+		ret = append(ret, resourceChange{Kind: "firewall", Name: fw.Name, Id: fw.Id, Action: actionDelete})
+	}
+	return ret, nil
+}
+
+// checkMaxDeletions verifies that number of deletions is allowed by max deletions guard
+func checkMaxDeletions(deletions []resourceChange, opts *pruneOptions) error {
+	if opts.MaxDeletions > 0 && len(deletions) > opts.MaxDeletions {
+		return fmt.Errorf("prune would delete %d resources, which is more than allowed maximum %d (see --max-deletions)", len(deletions), opts.MaxDeletions)
+	}
+	return nil
+}
+
+// checkPrune verifies that deletions are allowed by max deletions guard
+// and confirmed by user
+func checkPrune(deletions []resourceChange, opts *pruneOptions, in io.Reader, out io.Writer) error {
+	if len(deletions) == 0 {
+		return nil
+	}
+	if err := checkMaxDeletions(deletions, opts); err != nil {
+		return err
+	}
+	if opts.Yes {
+		return nil
+	}
+	fmt.Fprintln(out, "Following resources are not present in manifest and will be deleted:")
+	for _, rc := range deletions {
+		fmt.Fprintf(out, "  - %s %q (%s)\n", rc.Kind, rc.Name, rc.Id)
+	}
+	fmt.Fprint(out, "Do you really want to delete them? Only 'yes' will be accepted: ")
+	answer, _ := bufio.NewReader(in).ReadString('\n')
+	if strings.TrimSpace(answer) != "yes" {
+		return fmt.Errorf("prune cancelled")
+	}
+	return nil
+}
+
+// deleteResources deletes servers first and than firewalls, which can be used by them
+func deleteResources(deletions []resourceChange) error {
+	for _, kind := range []string{"server", "firewall"} {
+		for _, rc := range deletions {
+			if rc.Kind != kind {
+				continue
+			}
+			var err error
+			if kind == "server" {
+				err = deleteServer(rc.Id)
+			} else {
+				err = deleteFirewall(rc.Id)
+			}
+			if err != nil {
+				return fmt.Errorf("%s '%s': %s", rc.Kind, rc.Name, err)
+			}
+			fmt.Printf("%s '%s' deleted\n", rc.Kind, rc.Name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// plan with prune has to fail with the same max deletions guard as apply
+func TestPlanManifestMaxDeletions(t *testing.T) {
+	newTestApi(t)
+	for i := 1; i <= 3; i++ {
+		if _, _, err := ensureFirewall(Firewall{Name: fmt.Sprintf("old-%d", i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		name          string
+		opts          pruneOptions
+		wantDeletions int
+		wantErr       bool
+	}{
+		{name: "over limit", opts: pruneOptions{MaxDeletions: 2}, wantErr: true},
+		{name: "at limit", opts: pruneOptions{MaxDeletions: 3}, wantDeletions: 3},
+		{name: "no limit", opts: pruneOptions{}, wantDeletions: 3},
+		{name: "protected are not counted", opts: pruneOptions{MaxDeletions: 2, Protected: []string{"old-1"}}, wantDeletions: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := tt.opts
+			p, err := planManifest(&Manifest{}, &opts, nil)
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "--max-deletions") {
+					t.Fatalf("got error %v, want max deletions error", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(p.Resources) != tt.wantDeletions {
+				t.Errorf("got %d changes, want %d deletions", len(p.Resources), tt.wantDeletions)
+			}
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// exit code of reach command if flow is denied (1 is used for errors)
+const exitUnreachable = 2
+
+var reachFormats = []string{"text", "json"}
+
+// flow is network traffic of protocol to port, port is 0 for icmp
+type flow struct {
+	Protocol string `json:"protocol"`
+	Port     int    `json:"port,omitempty"`
+}
+
+func (f flow) String() string {
+	if f.Protocol == "icmp" {
+		return "icmp"
+	}
+	return fmt.Sprintf("%d/%s", f.Port, f.Protocol)
+}
+
+// parseFlow parses flow in format port/protocol (example: 5432/tcp) or icmp
+func parseFlow(s string) (flow, error) {
+	if s == "icmp" {
+		return flow{Protocol: "icmp"}, nil
+	}
+	port, protocol, ok := strings.Cut(s, "/")
+	if !ok || (protocol != "tcp" && protocol != "udp") {
+		return flow{}, fmt.Errorf("invalid port '%s', expected port/protocol (example: 5432/tcp, 53/udp) or icmp", s)
+	}
+	p, err := strconv.Atoi(port)
+	if err != nil || p < 1 || p > 65535 {
+		return flow{}, fmt.Errorf("invalid port number '%s'", port)
+	}
+	return flow{Protocol: protocol, Port: p}, nil
+}
+
+// ruleMatch is result of evaluation of one rule, Reason explains why rule does or does not match
+type ruleMatch struct {
+	Field   string `json:"field"`
+	Index   int    `json:"index"`
+	Rule    string `json:"rule"`
+	Matched bool   `json:"matched"`
+	Reason  string `json:"reason"`
+}
+
+// matchRule checks if rule allows flow from or to peer server,
+// rule with groups matches if peer is member of any of them
+func matchRule(r FirewallRule, f flow, peer Server) (bool, string) {
+	if r.Protocol != "any" && r.Protocol != f.Protocol {
+		return false, fmt.Sprintf("protocol %s does not match", r.Protocol)
+	}
+	if f.Protocol != "icmp" {
+		lo, hi, ok := portRange(r.Port)
+		if !ok || f.Port < lo || f.Port > hi {
+			return false, fmt.Sprintf("port %s does not match", r.Port)
+		}
+	}
+	if r.Host == "any" {
+		return true, "any host"
+	}
+	for _, g := range r.Groups {
+		if containsGroup(peer.Groups, g) {
+			return true, fmt.Sprintf("%s is member of group %s", peer.Name, strings.TrimPrefix(formatGroup(g), "name="))
+		}
+	}
+	return false, fmt.Sprintf("%s is not member of any of groups %s", peer.Name, groupNames(r.Groups))
+}
+
+// directionResult is evaluation of outbound rules of source or inbound rules of destination
+type directionResult struct {
+	Server   string      `json:"server"`
+	Firewall string      `json:"firewall"`
+	Allowed  bool        `json:"allowed"`
+	Rules    []ruleMatch `json:"rules"`
+}
+
+// evaluateRules evaluates rules against flow, first matching rule allows flow
+func evaluateRules(field string, rules []FirewallRule, f flow, peer Server) (bool, []ruleMatch) {
+	var ret []ruleMatch
+	for i, r := range rules {
+		ok, reason := matchRule(r, f, peer)
+		ret = append(ret, ruleMatch{Field: field, Index: i + 1, Rule: formatFirewallRule(r), Matched: ok, Reason: reason})
+		if ok {
+			return true, ret
+		}
+	}
+	return false, ret
+}
+
+// reachResult is result of simulation of flow from one server to another
+type reachResult struct {
+	From     string          `json:"from"`
+	To       string          `json:"to"`
+	Flow     flow            `json:"flow"`
+	Allowed  bool            `json:"allowed"`
+	Outbound directionResult `json:"outbound"`
+	Inbound  directionResult `json:"inbound"`
+}
+
+// evaluateReach checks if flow from server is allowed by output rules of its firewall
+// and by input rules of destination server firewall
+func evaluateReach(from Server, fromFw Firewall, to Server, toFw Firewall, f flow) reachResult {
+	ret := reachResult{From: from.Name, To: to.Name, Flow: f}
+	ret.Outbound = directionResult{Server: from.Name, Firewall: fromFw.Name}
+	ret.Outbound.Allowed, ret.Outbound.Rules = evaluateRules("rulesOut", fromFw.RulesOut, f, to)
+	ret.Inbound = directionResult{Server: to.Name, Firewall: toFw.Name}
+	ret.Inbound.Allowed, ret.Inbound.Rules = evaluateRules("rulesIn", toFw.RulesIn, f, from)
+	ret.Allowed = ret.Outbound.Allowed && ret.Inbound.Allowed
+	return ret
+}
+
+// serverFirewall returns firewall of server from snapshot
+func (t *tenantSnapshot) serverFirewall(s Server) (Firewall, error) {
+	for _, fw := range t.firewalls {
+		if fw.Id == s.Firewall.Id {
+			return fw, nil
+		}
+	}
+	return Firewall{}, fmt.Errorf("firewall %s of server '%s' not found", s.Firewall.Id, s.Name)
+}
+
+func (t *tenantSnapshot) serverByName(name string) (Server, error) {
+	for _, s := range t.servers {
+		if s.Name == name {
+			return s, nil
+		}
+	}
+	return Server{}, fmt.Errorf("server '%s' not found", name)
+}
+
+func printDirectionResult(w io.Writer, title string, d directionResult) {
+	verdict := "denied, no rule matches"
+	if d.Allowed {
+		verdict = "allowed"
+	}
+	fmt.Fprintf(w, "  %s (firewall '%s' of %s): %s\n", title, d.Firewall, d.Server, verdict)
+	if len(d.Rules) == 0 {
+		fmt.Fprintf(w, "    no rules\n")
+	}
+	for _, m := range d.Rules {
+		mark := "-"
+		if m.Matched {
+			mark = "+"
+		}
+		fmt.Fprintf(w, "    %s %s #%d %s: %s\n", mark, m.Field, m.Index, m.Rule, m.Reason)
+	}
+}
+
+func printReach(w io.Writer, format string, r reachResult) error {
+	switch format {
+	case "text":
+		verdict := "DENIED"
+		if r.Allowed {
+			verdict = "ALLOWED"
+		}
+		fmt.Fprintf(w, "%s -> %s %s: %s\n", r.From, r.To, r.Flow, verdict)
+		printDirectionResult(w, "outbound", r.Outbound)
+		printDirectionResult(w, "inbound", r.Inbound)
+		return nil
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(r)
+	}
+	return fmt.Errorf("unknown format '%s', supported formats are %s", format, strings.Join(reachFormats, ", "))
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+var (
+	testAdmins = Group{Id: "g:1", Name: "admins"}
+	testOps    = Group{Id: "g:2", Name: "ops"}
+)
+
+func TestParseFlow(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    flow
+		wantErr bool
+	}{
+		{value: "5432/tcp", want: flow{Protocol: "tcp", Port: 5432}},
+		{value: "53/udp", want: flow{Protocol: "udp", Port: 53}},
+		{value: "icmp", want: flow{Protocol: "icmp"}},
+		{value: "5432", wantErr: true},
+		{value: "80/icmp", wantErr: true},
+		{value: "0/tcp", wantErr: true},
+		{value: "65536/tcp", wantErr: true},
+		{value: "any/tcp", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseFlow(tt.value)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchRule(t *testing.T) {
+	admin := Server{Name: "admin-1", Groups: []Group{testAdmins}}
+	nobody := Server{Name: "other-1"}
+	tcp22 := flow{Protocol: "tcp", Port: 22}
+	tests := []struct {
+		name       string
+		rule       FirewallRule
+		flow       flow
+		peer       Server
+		want       bool
+		wantReason string
+	}{
+		{name: "any host", rule: FirewallRule{Protocol: "tcp", Port: "22", Host: "any"}, flow: tcp22, peer: nobody, want: true, wantReason: "any host"},
+		{name: "any protocol and port", rule: FirewallRule{Protocol: "any", Port: "any", Host: "any"}, flow: flow{Protocol: "udp", Port: 53}, peer: nobody, want: true},
+		{name: "any protocol allows icmp", rule: FirewallRule{Protocol: "any", Port: "any", Host: "any"}, flow: flow{Protocol: "icmp"}, peer: nobody, want: true},
+		{name: "icmp ignores port", rule: FirewallRule{Protocol: "icmp", Port: "any", Host: "any"}, flow: flow{Protocol: "icmp"}, peer: nobody, want: true},
+		{name: "other protocol", rule: FirewallRule{Protocol: "udp", Port: "22", Host: "any"}, flow: tcp22, peer: nobody, wantReason: "protocol udp does not match"},
+		{name: "icmp rule does not allow tcp", rule: FirewallRule{Protocol: "icmp", Port: "any", Host: "any"}, flow: tcp22, peer: nobody},
+		{name: "other port", rule: FirewallRule{Protocol: "tcp", Port: "80", Host: "any"}, flow: tcp22, peer: nobody, wantReason: "port 80 does not match"},
+		{name: "start of range", rule: FirewallRule{Protocol: "tcp", Port: "22-25", Host: "any"}, flow: tcp22, peer: nobody, want: true},
+		{name: "end of range", rule: FirewallRule{Protocol: "tcp", Port: "10-22", Host: "any"}, flow: tcp22, peer: nobody, want: true},
+		{name: "out of range", rule: FirewallRule{Protocol: "tcp", Port: "23-25", Host: "any"}, flow: tcp22, peer: nobody},
+		{name: "peer in group", rule: FirewallRule{Protocol: "tcp", Port: "22", Host: "group", Groups: []Group{testOps, testAdmins}}, flow: tcp22, peer: admin, want: true, wantReason: "admin-1 is member of group admins"},
+		{name: "group referenced by ID", rule: FirewallRule{Protocol: "tcp", Port: "22", Host: "group", Groups: []Group{{Id: "g:1"}}}, flow: tcp22, peer: admin, want: true, wantReason: "admin-1 is member of group id=g:1"},
+		{name: "peer not in group", rule: FirewallRule{Protocol: "tcp", Port: "22", Host: "group", Groups: []Group{testAdmins, testOps}}, flow: tcp22, peer: nobody, wantReason: "other-1 is not member of any of groups admins,ops"},
+		{name: "group rule without groups", rule: FirewallRule{Protocol: "tcp", Port: "22", Host: "group"}, flow: tcp22, peer: admin},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, reason := matchRule(tt.rule, tt.flow, tt.peer)
+			if got != tt.want {
+				t.Errorf("got %v (%s), want %v", got, reason, tt.want)
+			}
+			if tt.wantReason != "" && reason != tt.wantReason {
+				t.Errorf("got reason %q, want %q", reason, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestEvaluateRules(t *testing.T) {
+	peer := Server{Name: "admin-1", Groups: []Group{testAdmins}}
+	rules := []FirewallRule{
+		{Protocol: "udp", Port: "53", Host: "any"},
+		{Protocol: "tcp", Port: "22", Host: "group", Groups: []Group{testAdmins}},
+		{Protocol: "any", Port: "any", Host: "any"},
+	}
+	tests := []struct {
+		name        string
+		rules       []FirewallRule
+		flow        flow
+		want        bool
+		wantMatches []bool
+	}{
+		// rules after first matching rule are not evaluated
+		{name: "first matching rule", rules: rules, flow: flow{Protocol: "tcp", Port: 22}, want: true, wantMatches: []bool{false, true}},
+		{name: "last rule", rules: rules, flow: flow{Protocol: "icmp"}, want: true, wantMatches: []bool{false, false, true}},
+		{name: "no rule matches", rules: rules[:2], flow: flow{Protocol: "tcp", Port: 80}, wantMatches: []bool{false, false}},
+		{name: "no rules", flow: flow{Protocol: "tcp", Port: 22}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, matches := evaluateRules("rulesIn", tt.rules, tt.flow, peer)
+			if got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+			var gotMatches []bool
+			for i, m := range matches {
+				if m.Field != "rulesIn" || m.Index != i+1 || m.Rule != formatFirewallRule(tt.rules[i]) {
+					t.Errorf("unexpected rule match %+v", m)
+				}
+				gotMatches = append(gotMatches, m.Matched)
+			}
+			if !reflect.DeepEqual(gotMatches, tt.wantMatches) {
+				t.Errorf("got matches %v, want %v", gotMatches, tt.wantMatches)
+			}
+		})
+	}
+}
+
+// flow has to be allowed by output rules of source and by input rules of destination
+func TestEvaluateReach(t *testing.T) {
+	web := Server{Name: "web-1", Groups: []Group{testOps}}
+	db := Server{Name: "db-1", Groups: []Group{testAdmins}}
+	webFw := Firewall{Name: "web", RulesOut: []FirewallRule{{Protocol: "tcp", Port: "5000-5500", Host: "group", Groups: []Group{testAdmins}}}}
+	dbFw := Firewall{Name: "db", RulesIn: []FirewallRule{{Protocol: "tcp", Port: "5432", Host: "group", Groups: []Group{testOps}}}}
+	tests := []struct {
+		name         string
+		flow         flow
+		want         bool
+		wantOutbound bool
+		wantInbound  bool
+	}{
+		{name: "allowed by both", flow: flow{Protocol: "tcp", Port: 5432}, want: true, wantOutbound: true, wantInbound: true},
+		{name: "denied by inbound", flow: flow{Protocol: "tcp", Port: 5000}, wantOutbound: true},
+		{name: "denied by both", flow: flow{Protocol: "udp", Port: 5432}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := evaluateReach(web, webFw, db, dbFw, tt.flow)
+			if r.Allowed != tt.want || r.Outbound.Allowed != tt.wantOutbound || r.Inbound.Allowed != tt.wantInbound {
+				t.Errorf("got allowed %v (outbound %v, inbound %v), want %v (outbound %v, inbound %v)",
+					r.Allowed, r.Outbound.Allowed, r.Inbound.Allowed, tt.want, tt.wantOutbound, tt.wantInbound)
+			}
+			if r.Outbound.Firewall != "web" || r.Inbound.Firewall != "db" {
+				t.Errorf("got firewalls %s and %s, want web and db", r.Outbound.Firewall, r.Inbound.Firewall)
+			}
+		})
+	}
+	// reverse direction is not allowed by any rule
+	if r := evaluateReach(db, dbFw, web, webFw, flow{Protocol: "tcp", Port: 5432}); r.Allowed {
+		t.Error("reverse flow is allowed")
+	}
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/shieldoo/shieldoo-cli/client"
+)
+
+// default output rule used when firewall has no output rules defined
+var defaultFirewallRuleOut = FirewallRule{
+	Protocol: "any",
+	Port:     "any",
+	Host:     "any",
+}
+
+// getFirewall returns firewall by name or ID, nil is returned if firewall does not exist
+func getFirewall(name string, id string) (*Firewall, error) {
+	c, err := apiClient()
+	if err != nil {
+		return nil, err
+	}
+	if id == "" {
+		return c.GetFirewallByName(apiContext(), name)
+	}
+	fw, err := c.GetFirewall(apiContext(), id)
+	if client.IsNotFound(err) {
+		return nil, nil
+	}
+	return fw, err
+}
+
+// getServer returns server by name or ID, nil is returned if server does not exist
+func getServer(name string, id string) (*Server, error) {
+	c, err := apiClient()
+	if err != nil {
+		return nil, err
+	}
+	if id == "" {
+		return c.GetServerByName(apiContext(), name)
+	}
+	server, err := c.GetServer(apiContext(), id)
+	if client.IsNotFound(err) {
+		return nil, nil
+	}
+	return server, err
+}
+
+// getGroup returns group by name or ID, nil is returned if group does not exist
+func getGroup(name string, id string) (*Group, error) {
+	c, err := apiClient()
+	if err != nil {
+		return nil, err
+	}
+	if id == "" {
+		return c.GetGroupByName(apiContext(), name)
+	}
+	group, err := c.GetGroup(apiContext(), id)
+	if client.IsNotFound(err) {
+		return nil, nil
+	}
+	return group, err
+}
+
+func findFirewallByName(name string) (*Firewall, error) {
+	return getFirewall(name, "")
+}
+
+func findServerByName(name string) (*Server, error) {
+	return getServer(name, "")
+}
+
+// ensureFirewall creates or updates firewall identified by name,
+// returns stored firewall and flag if firewall was created
+func ensureFirewall(fw Firewall) (*Firewall, bool, error) {
+	// convert FW name to ID
+	current, err := findFirewallByName(fw.Name)
+	if err != nil {
+		return nil, false, err
+	}
+	return saveFirewall(fw, current)
+}
+
+// saveFirewall updates current firewall or creates new one if current is nil,
+// returns stored firewall and flag if firewall was created
+func saveFirewall(fw Firewall, current *Firewall) (*Firewall, bool, error) {
+	c, err := apiClient()
+	if err != nil {
+		return nil, false, err
+	}
+	// if out rules empty, create default
+	fw = withDefaultRulesOut(fw)
+	// groups are resolved before request, so unknown groups are reported with suggestions
+	fw, err = resolveFirewallGroups(fw)
+	if err != nil {
+		return nil, false, err
+	}
+	if current != nil {
+		// update
+		fw.Id = current.Id
+		stored, err := c.UpdateFirewall(apiContext(), fw)
+		return stored, false, err
+	}
+	// create
+	stored, err := c.CreateFirewall(apiContext(), fw)
+	return stored, true, err
+}
+
+// resolveServerFirewall fills firewall ID of server from firewall name
+func resolveServerFirewall(server *Server) error {
+	if server.Firewall.Id != "" {
+		server.Firewall = Firewall{Id: server.Firewall.Id}
+		return nil
+	}
+	if server.Firewall.Name == "" {
+		return fmt.Errorf("either firewall id or firewall name must be specified for server '%s'", server.Name)
+	}
+	fw, err := findFirewallByName(server.Firewall.Name)
+	if err != nil {
+		return err
+	}
+	if fw == nil {
+		return fmt.Errorf("no firewall found with name '%s'", server.Firewall.Name)
+	}
+	server.Firewall = Firewall{Id: fw.Id}
+	return nil
+}
+
+// ensureServer creates or updates server identified by name,
+// returns stored server and flag if server was created
+func ensureServer(server Server) (*Server, bool, error) {
+	// convert server name to id
+	current, err := findServerByName(server.Name)
+	if err != nil {
+		return nil, false, err
+	}
+	return saveServer(server, current)
+}
+
+// saveServer updates current server or creates new one if current is nil,
+// returns stored server and flag if server was created
+func saveServer(server Server, current *Server) (*Server, bool, error) {
+	c, err := apiClient()
+	if err != nil {
+		return nil, false, err
+	}
+	if err := resolveServerFirewall(&server); err != nil {
+		return nil, false, err
+	}
+	server, err = resolveServerGroups(server)
+	if err != nil {
+		return nil, false, err
+	}
+	if current != nil {
+		// server already exists
+		server.Id = current.Id
+		stored, err := c.UpdateServer(apiContext(), server)
+		return stored, false, err
+	}
+	// create server
+	stored, err := c.CreateServer(apiContext(), server)
+	return stored, true, err
+}
+
+// ensureGroup creates or updates group identified by name,
+// returns stored group and flag if group was created
+func ensureGroup(g Group) (*Group, bool, error) {
+	c, err := apiClient()
+	if err != nil {
+		return nil, false, err
+	}
+	// cached groups are not valid anymore
+	cachedGroupResolver = nil
+	current, err := getGroup(g.Name, "")
+	if err != nil {
+		return nil, false, err
+	}
+	if current != nil {
+		g.Id = current.Id
+		stored, err := c.UpdateGroup(apiContext(), g)
+		return stored, false, err
+	}
+	stored, err := c.CreateGroup(apiContext(), g)
+	return stored, true, err
+}
+
+func listFirewalls() ([]Firewall, error) {
+	c, err := apiClient()
+	if err != nil {
+		return nil, err
+	}
+	return c.ListFirewalls(apiContext())
+}
+
+func listServers() ([]Server, error) {
+	c, err := apiClient()
+	if err != nil {
+		return nil, err
+	}
+	return c.ListServers(apiContext())
+}
+
+func listGroups() ([]Group, error) {
+	c, err := apiClient()
+	if err != nil {
+		return nil, err
+	}
+	return c.ListGroups(apiContext())
+}
+
+func deleteFirewall(id string) error {
+	c, err := apiClient()
+	if err != nil {
+		return err
+	}
+	return c.DeleteFirewall(apiContext(), id)
+}
+
+func deleteServer(id string) error {
+	c, err := apiClient()
+	if err != nil {
+		return err
+	}
+	return c.DeleteServer(apiContext(), id)
+}
+
+func deleteGroup(id string) error {
+	c, err := apiClient()
+	if err != nil {
+		return err
+	}
+	cachedGroupResolver = nil
+	return c.DeleteGroup(apiContext(), id)
+}
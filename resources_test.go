@@ -0,0 +1,254 @@
+package main
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/shieldoo/shieldoo-cli/client"
+	"github.com/shieldoo/shieldoo-cli/mockapi"
+)
+
+// newTestApi starts fake API with group admins and points API client of commands to it
+func newTestApi(t *testing.T) *mockapi.Server {
+	t.Helper()
+	api := mockapi.New("test-key", "")
+	api.AddGroup(client.Group{Name: "admins"})
+	srv := httptest.NewServer(api)
+	rootCmd.SetContext(context.Background())
+	shieldooClient = client.New(srv.URL, "test-key")
+	cachedGroupResolver = nil
+	t.Cleanup(func() {
+		srv.Close()
+		shieldooClient = nil
+		cachedGroupResolver = nil
+	})
+	return api
+}
+
+func TestEnsureFirewall(t *testing.T) {
+	newTestApi(t)
+	tests := []struct {
+		name        string
+		firewall    Firewall
+		wantCreated bool
+		wantRules   int
+	}{
+		{
+			name:        "create",
+			firewall:    Firewall{Name: "web", RulesIn: []FirewallRule{{Protocol: "tcp", Port: "443", Host: "any"}}},
+			wantCreated: true,
+			wantRules:   1,
+		},
+		{
+			name: "update",
+			firewall: Firewall{Name: "web", RulesIn: []FirewallRule{
+				{Protocol: "tcp", Port: "443", Host: "any"},
+				{Protocol: "tcp", Port: "22", Host: "group", Groups: []Group{{Name: "admins"}}},
+			}},
+			wantCreated: false,
+			wantRules:   2,
+		},
+	}
+	var id string
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fw, created, err := ensureFirewall(tt.firewall)
+			if err != nil {
+				t.Fatalf("ensureFirewall: %s", err)
+			}
+			if created != tt.wantCreated {
+				t.Errorf("created = %v, want %v", created, tt.wantCreated)
+			}
+			if id != "" && fw.Id != id {
+				t.Errorf("firewall ID changed from %s to %s", id, fw.Id)
+			}
+			id = fw.Id
+			if len(fw.RulesIn) != tt.wantRules {
+				t.Errorf("got %d rules, want %d", len(fw.RulesIn), tt.wantRules)
+			}
+			if len(fw.RulesOut) != 1 || formatFirewallRule(fw.RulesOut[0]) != formatFirewallRule(defaultFirewallRuleOut) {
+				t.Errorf("default output rule not created: %+v", fw.RulesOut)
+			}
+		})
+	}
+}
+
+func TestEnsureServer(t *testing.T) {
+	newTestApi(t)
+	if _, _, err := ensureFirewall(Firewall{Name: "default"}); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name          string
+		server        Server
+		wantCreated   bool
+		wantErr       bool
+		wantListeners int
+	}{
+		{
+			name:        "create",
+			server:      Server{Name: "db", Firewall: Firewall{Name: "default"}, Groups: []Group{{Name: "admins"}}},
+			wantCreated: true,
+		},
+		{
+			name: "update",
+			server: Server{Name: "db", Firewall: Firewall{Name: "default"},
+				Listeners: []Listener{{ListenPort: 5432, Protocol: "tcp", ForwardPort: 5432, ForwardHost: "10.0.0.5"}}},
+			wantListeners: 1,
+		},
+		{
+			name:    "unknown firewall",
+			server:  Server{Name: "db", Firewall: Firewall{Name: "missing"}},
+			wantErr: true,
+		},
+		{
+			name:    "unknown group",
+			server:  Server{Name: "db", Firewall: Firewall{Name: "default"}, Groups: []Group{{Name: "admns"}}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, created, err := ensureServer(tt.server)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ensureServer: %s", err)
+			}
+			if created != tt.wantCreated {
+				t.Errorf("created = %v, want %v", created, tt.wantCreated)
+			}
+			if len(s.Listeners) != tt.wantListeners {
+				t.Errorf("got %d listeners, want %d", len(s.Listeners), tt.wantListeners)
+			}
+			if s.IpAddress == "" || s.Configuration == "" {
+				t.Errorf("server fields assigned by API are missing: %+v", s)
+			}
+		})
+	}
+}
+
+func TestDeleteServerAndFirewall(t *testing.T) {
+	newTestApi(t)
+	fw, _, err := ensureFirewall(Firewall{Name: "default"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, _, err := ensureServer(Server{Name: "db", Firewall: Firewall{Id: fw.Id}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// firewall used by server can not be deleted
+	if err := deleteFirewall(fw.Id); err == nil {
+		t.Error("expected error when deleting firewall used by server")
+	}
+	if err := deleteServer(s.Id); err != nil {
+		t.Fatalf("deleteServer: %s", err)
+	}
+	if err := deleteFirewall(fw.Id); err != nil {
+		t.Fatalf("deleteFirewall: %s", err)
+	}
+	if got, err := getServer("", s.Id); err != nil || got != nil {
+		t.Errorf("getServer after delete = %+v, %v, want nil", got, err)
+	}
+	if got, err := getFirewall("", fw.Id); err != nil || got != nil {
+		t.Errorf("getFirewall after delete = %+v, %v, want nil", got, err)
+	}
+}
+
+func TestFindByName(t *testing.T) {
+	newTestApi(t)
+	for _, name := range []string{"web", "web-2", "db"} {
+		if _, _, err := ensureFirewall(Firewall{Name: name}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, _, err := ensureServer(Server{Name: "web-1", Firewall: Firewall{Name: "web"}}); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name     string
+		find     func(string) (string, error)
+		lookup   string
+		wantName string
+	}{
+		{"firewall", firewallName, "web", "web"},
+		{"firewall with prefix of other name", firewallName, "web-2", "web-2"},
+		{"missing firewall", firewallName, "we", ""},
+		{"server", serverName, "web-1", "web-1"},
+		{"missing server", serverName, "web", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.find(tt.lookup)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.wantName {
+				t.Errorf("found %q, want %q", got, tt.wantName)
+			}
+		})
+	}
+}
+
+func firewallName(name string) (string, error) {
+	fw, err := findFirewallByName(name)
+	if fw == nil {
+		return "", err
+	}
+	return fw.Name, err
+}
+
+func serverName(name string) (string, error) {
+	s, err := findServerByName(name)
+	if s == nil {
+		return "", err
+	}
+	return s.Name, err
+}
+
+func TestGroupUsages(t *testing.T) {
+	newTestApi(t)
+	rule := FirewallRule{Protocol: "tcp", Port: "22", Host: "group", Groups: []Group{{Name: "admins"}}}
+	if _, _, err := ensureFirewall(Firewall{Name: "web", RulesIn: []FirewallRule{rule}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := ensureFirewall(Firewall{Name: "db"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []Server{
+		{Name: "web-1", Firewall: Firewall{Name: "web"}},
+		{Name: "db-1", Firewall: Firewall{Name: "db"}, Groups: []Group{{Name: "admins"}}},
+	} {
+		if _, _, err := ensureServer(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	g, err := getGroup("admins", "")
+	if err != nil || g == nil {
+		t.Fatalf("getGroup = %v, %v", g, err)
+	}
+	snapshot, err := loadTenantSnapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	u := snapshot.groupUsages(*g)
+	if len(u.Servers) != 1 || u.Servers[0].Name != "db-1" {
+		t.Errorf("member servers %+v, want db-1", u.Servers)
+	}
+	if len(u.FirewallRules) != 1 {
+		t.Fatalf("got %d firewall rules, want 1: %+v", len(u.FirewallRules), u.FirewallRules)
+	}
+	r := u.FirewallRules[0]
+	if r.Firewall != "web" || r.Field != "rulesIn" || r.Index != 1 || r.Rule != "tcp;22;group;name=admins" {
+		t.Errorf("unexpected rule reference %+v", r)
+	}
+	if len(r.Servers) != 1 || r.Servers[0].Name != "web-1" {
+		t.Errorf("servers of firewall %+v, want web-1", r.Servers)
+	}
+}
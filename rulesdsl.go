@@ -0,0 +1,233 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Firewall rules can be written in human readable language, one rule per line:
+//
+//	# SSH for admins only
+//	allow in tcp 22 from group "admins"
+//	allow in tcp 443 from group "admins", id="demo.shieldoo.net:groups:1", objectId="e7549a43-f3c2-4d0d-9cd1-6811a107cdc4"
+//	allow in udp 16000-16999 from any
+//	allow in icmp from any
+//	allow out any to any
+//
+// Port can be omitted for any port, "any" can be used too. Input rules use "from",
+// output rules use "to". Group is referenced by name (quoted string or name="..."),
+// id="..." or objectId="...". Text after # is comment.
+
+// ruleToken is word, comma or quoted string, key is set for key="value" (text is value)
+type ruleToken struct {
+	key    string
+	text   string
+	quoted bool
+}
+
+func (t ruleToken) String() string {
+	if t.key != "" {
+		return t.key + "=" + strconv.Quote(t.text)
+	}
+	return t.text
+}
+
+// tokenizeRule splits line to words, quoted strings and commas, comment is removed
+func tokenizeRule(line string) ([]ruleToken, error) {
+	var tokens []ruleToken
+	for i := 0; i < len(line); {
+		c := line[i]
+		switch {
+		case c == '#':
+			return tokens, nil
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+		case c == ',':
+			tokens = append(tokens, ruleToken{text: ","})
+			i++
+		case c == '"':
+			// find closing quote, escaped quotes are allowed
+			j := i + 1
+			for ; j < len(line) && line[j] != '"'; j++ {
+				if line[j] == '\\' {
+					j++
+				}
+			}
+			if j >= len(line) {
+				return nil, fmt.Errorf("unterminated string")
+			}
+			s, err := strconv.Unquote(line[i : j+1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid string %s", line[i:j+1])
+			}
+			// key="value" without spaces is one token, value is kept apart, so it can contain "="
+			if n := len(tokens); n > 0 && line[i-1] == '=' && !tokens[n-1].quoted {
+				key := strings.TrimSuffix(tokens[n-1].text, "=")
+				if key == "" {
+					return nil, fmt.Errorf("missing key before =%s", line[i:j+1])
+				}
+				tokens[n-1] = ruleToken{key: key, text: s, quoted: true}
+			} else {
+				tokens = append(tokens, ruleToken{text: s, quoted: true})
+			}
+			i = j + 1
+		default:
+			j := i
+			for ; j < len(line) && !strings.ContainsRune(" \t\r,\"#", rune(line[j])); j++ {
+			}
+			tokens = append(tokens, ruleToken{text: line[i:j]})
+			i = j
+		}
+	}
+	return tokens, nil
+}
+
+// parseRuleGroupRef parses group reference, quoted string is group name
+func parseRuleGroupRef(t ruleToken) (Group, error) {
+	if t.quoted && t.key == "" {
+		return Group{Name: t.text}, nil
+	}
+	if t.text == "," && !t.quoted {
+		return Group{}, fmt.Errorf("group expected")
+	}
+	key, value, ok := t.key, t.text, true
+	if !t.quoted {
+		key, value, ok = strings.Cut(t.text, "=")
+	}
+	if !ok || value == "" {
+		return Group{}, fmt.Errorf("invalid group '%s', use \"name\", name=\"...\", id=\"...\" or objectId=\"...\"", t)
+	}
+	switch strings.ToLower(key) {
+	case "name":
+		return Group{Name: value}, nil
+	case "id":
+		return Group{Id: value}, nil
+	case "objectid":
+		return Group{ObjectId: value}, nil
+	}
+	return Group{}, fmt.Errorf("invalid group '%s', use \"name\", name=\"...\", id=\"...\" or objectId=\"...\"", t)
+}
+
+// parseRuleLine parses one rule, returns direction ("in" or "out") and rule,
+// rule is nil for empty line or comment
+func parseRuleLine(line string) (string, *FirewallRule, error) {
+	tokens, err := tokenizeRule(line)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(tokens) == 0 {
+		return "", nil, nil
+	}
+	pos := 0
+	next := func() (ruleToken, bool) {
+		if pos >= len(tokens) {
+			return ruleToken{}, false
+		}
+		pos++
+		return tokens[pos-1], true
+	}
+	expect := func(what string, values ...string) (string, error) {
+		t, ok := next()
+		if !ok {
+			return "", fmt.Errorf("unexpected end of rule, expected %s", what)
+		}
+		for _, v := range values {
+			if !t.quoted && t.text == v {
+				return v, nil
+			}
+		}
+		return "", fmt.Errorf("unexpected '%s', expected %s", t, what)
+	}
+
+	if _, err := expect("'allow'", "allow"); err != nil {
+		return "", nil, err
+	}
+	direction, err := expect("'in' or 'out'", "in", "out")
+	if err != nil {
+		return "", nil, err
+	}
+	rule := FirewallRule{Port: "any"}
+	if rule.Protocol, err = expect("protocol (any, icmp, tcp or udp)", "any", "icmp", "tcp", "udp"); err != nil {
+		return "", nil, err
+	}
+	peer := "from"
+	if direction == "out" {
+		peer = "to"
+	}
+	// port is optional
+	t, ok := next()
+	if !ok {
+		return "", nil, fmt.Errorf("unexpected end of rule, expected port or '%s'", peer)
+	}
+	if t.quoted || t.text != peer {
+		rule.Port = t.String()
+		if _, err := expect("'"+peer+"'", peer); err != nil {
+			return "", nil, err
+		}
+	}
+	if rule.Host, err = expect("'any' or 'group'", "any", "group"); err != nil {
+		return "", nil, err
+	}
+	if rule.Host == "group" {
+		for {
+			t, ok := next()
+			if !ok {
+				return "", nil, fmt.Errorf("unexpected end of rule, expected group")
+			}
+			g, err := parseRuleGroupRef(t)
+			if err != nil {
+				return "", nil, err
+			}
+			rule.Groups = append(rule.Groups, g)
+			if t, ok := next(); !ok {
+				break
+			} else if t.text != "," || t.quoted {
+				return "", nil, fmt.Errorf("unexpected '%s', expected ',' or end of rule", t)
+			}
+		}
+	}
+	if t, ok := next(); ok {
+		return "", nil, fmt.Errorf("unexpected '%s', expected end of rule", t)
+	}
+	if err := validateFirewallRule(rule); err != nil {
+		return "", nil, err
+	}
+	return direction, &rule, nil
+}
+
+// parseRulesDSL parses rules written in rule language, name is used in error messages
+func parseRulesDSL(r io.Reader, name string) ([]FirewallRule, []FirewallRule, error) {
+	var rulesIn, rulesOut []FirewallRule
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		direction, rule, err := parseRuleLine(scanner.Text())
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s:%d: %s", name, line, err)
+		}
+		switch {
+		case rule == nil:
+		case direction == "in":
+			rulesIn = append(rulesIn, *rule)
+		default:
+			rulesOut = append(rulesOut, *rule)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, fmt.Errorf("%s: %s", name, err)
+	}
+	return rulesIn, rulesOut, nil
+}
+
+// loadRulesFile reads input and output rules from file written in rule language
+func loadRulesFile(path string) ([]FirewallRule, []FirewallRule, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+	return parseRulesDSL(f, path)
+}
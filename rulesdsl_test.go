@@ -0,0 +1,83 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseRuleLine(t *testing.T) {
+	tests := []struct {
+		line          string
+		wantDirection string
+		wantRule      *FirewallRule
+		wantErr       bool
+	}{
+		{line: "# comment"},
+		{
+			line:          "allow in tcp 22 from group \"admins\"",
+			wantDirection: "in",
+			wantRule:      &FirewallRule{Protocol: "tcp", Port: "22", Host: "group", Groups: []Group{{Name: "admins"}}},
+		},
+		{
+			line:          "allow out any to any # default",
+			wantDirection: "out",
+			wantRule:      &FirewallRule{Protocol: "any", Port: "any", Host: "any"},
+		},
+		{
+			line:          "allow in tcp 443 from group \"a=b\", name=\"c=d\", id=\"x:groups:1\", objectId=e7549a43",
+			wantDirection: "in",
+			wantRule: &FirewallRule{Protocol: "tcp", Port: "443", Host: "group",
+				Groups: []Group{{Name: "a=b"}, {Name: "c=d"}, {Id: "x:groups:1"}, {ObjectId: "e7549a43"}}},
+		},
+		{
+			line:          "allow in udp 53 from group \"ops, \\\"night\\\"\"",
+			wantDirection: "in",
+			wantRule:      &FirewallRule{Protocol: "udp", Port: "53", Host: "group", Groups: []Group{{Name: "ops, \"night\""}}},
+		},
+		{line: "allow in tcp 22 from group email=\"a=b\"", wantErr: true},
+		{line: "allow in tcp 22 from group name=\"\"", wantErr: true},
+		{line: "allow in tcp 22 from group \"admins\" \"ops\"", wantErr: true},
+		{line: "allow in tcp port=\"22\" from any", wantErr: true},
+		{line: "allow in tcp 22 from group \"admins", wantErr: true},
+		{line: "allow in tcp 22 from group = \"admins\"", wantErr: true},
+		{line: "allow in tcp 22 from group id= \"x:groups:1\"", wantErr: true},
+		{line: "allow in tcp 22 from group =\"admins\"", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			direction, rule, err := parseRuleLine(tt.line)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %+v", rule)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if direction != tt.wantDirection || !reflect.DeepEqual(rule, tt.wantRule) {
+				t.Errorf("got %s %+v, want %s %+v", direction, rule, tt.wantDirection, tt.wantRule)
+			}
+		})
+	}
+}
+
+func TestParseRulesDSLErrorLine(t *testing.T) {
+	tests := []struct {
+		rules   string
+		wantErr string
+	}{
+		{"allow out any to any\nallow in tcp 22 from group =\"admins\"\n", "rules.txt:2: missing key before =\"admins\""},
+		{"# ssh\nallow in tcp 22 from group name = \"admins\"\n", "rules.txt:2: invalid group 'name'"},
+		{"allow in tcp 22 from group id= \"x\"\n", "rules.txt:1: invalid group 'id='"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.wantErr, func(t *testing.T) {
+			_, _, err := parseRulesDSL(strings.NewReader(tt.rules), "rules.txt")
+			if err == nil || !strings.HasPrefix(err.Error(), tt.wantErr) {
+				t.Errorf("got error %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+)
+
+// patchedServer returns copy of current server which can be modified by patch and written back,
+// firewall is referenced by ID and server-owned fields are removed
+func patchedServer(current Server) Server {
+	server := current
+	server.Groups = append([]Group(nil), current.Groups...)
+	server.Listeners = append([]Listener(nil), current.Listeners...)
+	server.Firewall = Firewall{Id: current.Firewall.Id}
+	server.Configuration = ""
+	return server
+}
+
+// addServerListeners adds listeners to server, listener with the same port and protocol
+// is not replaced (it has to be removed first), the same listener is added only once
+func addServerListeners(server *Server, listeners []Listener) error {
+	for _, l := range listeners {
+		exists := false
+		for _, current := range server.Listeners {
+			if current.ListenPort != l.ListenPort || current.Protocol != l.Protocol {
+				continue
+			}
+			if current != l {
+				return fmt.Errorf("server '%s' already has listener on port %d/%s (%s), remove it first",
+					server.Name, l.ListenPort, l.Protocol, formatListener(current))
+			}
+			exists = true
+		}
+		if !exists {
+			server.Listeners = append(server.Listeners, l)
+		}
+	}
+	return nil
+}
+
+// removeServerListeners removes listeners on port, all protocols are matched if protocol is empty
+func removeServerListeners(server *Server, port int, protocol string) error {
+	var kept []Listener
+	for _, l := range server.Listeners {
+		if l.ListenPort == port && (protocol == "" || l.Protocol == protocol) {
+			continue
+		}
+		kept = append(kept, l)
+	}
+	if len(kept) == len(server.Listeners) {
+		if protocol != "" {
+			return fmt.Errorf("server '%s' has no listener on port %d/%s", server.Name, port, protocol)
+		}
+		return fmt.Errorf("server '%s' has no listener on port %d", server.Name, port)
+	}
+	server.Listeners = kept
+	return nil
+}
+
+// addServerGroups adds groups to server, group references are resolved to existing groups
+func addServerGroups(server *Server, refs []Group) error {
+	r, err := getGroupResolver()
+	if err != nil {
+		return err
+	}
+	for _, ref := range refs {
+		g, err := r.resolve(ref)
+		if err != nil {
+			return err
+		}
+		if !containsGroup(server.Groups, Group{Id: g.Id}) {
+			server.Groups = append(server.Groups, g)
+		}
+	}
+	return nil
+}
+
+// removeServerGroups removes groups from server, server has to be member of all of them
+func removeServerGroups(server *Server, refs []Group) error {
+	r, err := getGroupResolver()
+	if err != nil {
+		return err
+	}
+	for _, ref := range refs {
+		g, err := r.resolve(ref)
+		if err != nil {
+			return err
+		}
+		var kept []Group
+		for _, current := range server.Groups {
+			if current.Id != g.Id {
+				kept = append(kept, current)
+			}
+		}
+		if len(kept) == len(server.Groups) {
+			return fmt.Errorf("server '%s' is not member of group %s", server.Name, formatGroup(ref))
+		}
+		server.Groups = kept
+	}
+	return nil
+}
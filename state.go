@@ -0,0 +1,234 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+const stateVersion = 1
+
+// stateEntry is server-side identity of resource declared in manifest
+type stateEntry struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// stateFile maps manifest keys of firewalls and servers to their IDs,
+// so resources can be renamed and resources deleted outside of manifest are detected
+type stateFile struct {
+	Version   int                   `json:"version"`
+	Firewalls map[string]stateEntry `json:"firewalls"`
+	Servers   map[string]stateEntry `json:"servers"`
+	backend   stateBackend
+	// ID of lock held by this process, empty if state is not locked
+	lockId string
+}
+
+func addStateFlag(cmd *cobra.Command) {
+	cmd.Flags().String("state", "", "State file (JSON) which maps manifest keys to IDs of firewalls and servers,\n"+
+		"	enables renames and detection of resources deleted outside of manifest,\n"+
+		"	local path, http(s)://... URL or s3://bucket/key")
+}
+
+// loadState reads state from location, empty state is returned if state does not exist
+func loadState(location string) (*stateFile, error) {
+	b, err := newStateBackend(location)
+	if err != nil {
+		return nil, err
+	}
+	return readState(b)
+}
+
+func readState(b stateBackend) (*stateFile, error) {
+	st := &stateFile{
+		Version:   stateVersion,
+		Firewalls: map[string]stateEntry{},
+		Servers:   map[string]stateEntry{},
+		backend:   b,
+	}
+	data, err := b.read()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", b, err)
+	}
+	if data == nil {
+		return st, nil
+	}
+	if err := json.Unmarshal(data, st); err != nil {
+		return nil, fmt.Errorf("%s: %s", b, err)
+	}
+	if st.Version != stateVersion {
+		return nil, fmt.Errorf("%s: unsupported state version %d", b, st.Version)
+	}
+	if st.Firewalls == nil {
+		st.Firewalls = map[string]stateEntry{}
+	}
+	if st.Servers == nil {
+		st.Servers = map[string]stateEntry{}
+	}
+	return st, nil
+}
+
+// lockState locks state and reads it, if state is locked by someone else
+// lock is retried until timeout expires
+func lockState(location string, operation string, timeout time.Duration) (*stateFile, error) {
+	b, err := newStateBackend(location)
+	if err != nil {
+		return nil, err
+	}
+	lock := newStateLock(operation)
+	deadline := time.Now().Add(timeout)
+	waiting := false
+	for {
+		err = b.lock(lock)
+		var locked *stateLockedError
+		if !errors.As(err, &locked) || time.Now().After(deadline) {
+			break
+		}
+		if !waiting {
+			fmt.Printf("%s, waiting for lock ...\n", err)
+			waiting = true
+		}
+		time.Sleep(stateLockRetryInterval)
+	}
+	if err != nil {
+		return nil, err
+	}
+	st, err := readState(b)
+	if err != nil {
+		b.unlock(lock.ID)
+		return nil, err
+	}
+	st.lockId = lock.ID
+	return st, nil
+}
+
+// interval of lock attempts when waiting for lock
+var stateLockRetryInterval = 2 * time.Second
+
+func (s *stateFile) unlock() error {
+	if s.lockId == "" {
+		return nil
+	}
+	err := s.backend.unlock(s.lockId)
+	s.lockId = ""
+	return err
+}
+
+func (s *stateFile) save() error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := s.backend.write(append(data, '\n')); err != nil {
+		return fmt.Errorf("%s: %s", s.backend, err)
+	}
+	return nil
+}
+
+// retain removes entries of keys which are not declared in manifest
+func (s *stateFile) retain(m *Manifest) {
+	firewalls := map[string]bool{}
+	for _, fw := range m.Firewalls {
+		firewalls[fw.key()] = true
+	}
+	for k := range s.Firewalls {
+		if !firewalls[k] {
+			delete(s.Firewalls, k)
+		}
+	}
+	servers := map[string]bool{}
+	for _, srv := range m.Servers {
+		servers[srv.key()] = true
+	}
+	for k := range s.Servers {
+		if !servers[k] {
+			delete(s.Servers, k)
+		}
+	}
+}
+
+// resourceLocator finds current firewalls and servers declared in manifest,
+// without state resources are found by name, with state by ID and all resources are listed only once
+type resourceLocator struct {
+	state    *stateFile
+	snapshot *tenantSnapshot
+}
+
+func newResourceLocator(st *stateFile) (*resourceLocator, error) {
+	l := &resourceLocator{state: st}
+	if st == nil {
+		return l, nil
+	}
+	var err error
+	l.snapshot, err = loadTenantSnapshot()
+	if err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// firewall returns current firewall (nil if it does not exist) and flag
+// if firewall is tracked in state, but it was deleted outside of manifest
+func (l *resourceLocator) firewall(fw manifestFirewall) (*Firewall, bool, error) {
+	if l.snapshot == nil {
+		current, err := findFirewallByName(fw.Name)
+		return current, false, err
+	}
+	entry, tracked := l.state.Firewalls[fw.key()]
+	for i := range l.snapshot.firewalls {
+		if tracked && l.snapshot.firewalls[i].Id == entry.Id {
+			return &l.snapshot.firewalls[i], false, nil
+		}
+	}
+	for i := range l.snapshot.firewalls {
+		if l.snapshot.firewalls[i].Name == fw.Name {
+			return &l.snapshot.firewalls[i], false, nil
+		}
+	}
+	return nil, tracked, nil
+}
+
+// server returns current server (nil if it does not exist) and flag
+// if server is tracked in state, but it was deleted outside of manifest
+func (l *resourceLocator) server(s manifestServer) (*Server, bool, error) {
+	if l.snapshot == nil {
+		current, err := findServerByName(s.Name)
+		return current, false, err
+	}
+	entry, tracked := l.state.Servers[s.key()]
+	for i := range l.snapshot.servers {
+		if tracked && l.snapshot.servers[i].Id == entry.Id {
+			return &l.snapshot.servers[i], false, nil
+		}
+	}
+	for i := range l.snapshot.servers {
+		if l.snapshot.servers[i].Name == s.Name {
+			return &l.snapshot.servers[i], false, nil
+		}
+	}
+	return nil, tracked, nil
+}
+
+// managedIds returns IDs of existing resources tracked in state for keys declared in manifest,
+// prune does not delete them even if they are renamed by manifest
+func (l *resourceLocator) managedIds(m *Manifest) map[string]bool {
+	ret := map[string]bool{}
+	if l.state == nil {
+		return ret
+	}
+	for _, fw := range m.Firewalls {
+		if e, ok := l.state.Firewalls[fw.key()]; ok {
+			ret[e.Id] = true
+		}
+	}
+	for _, s := range m.Servers {
+		if e, ok := l.state.Servers[s.key()]; ok {
+			ret[e.Id] = true
+		}
+	}
+	return ret
+}
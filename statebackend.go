@@ -0,0 +1,138 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// stateBackend stores state file and its lock
+type stateBackend interface {
+	// read returns stored state, nil is returned if state does not exist yet
+	read() ([]byte, error)
+	write(data []byte) error
+	// lock acquires exclusive lock, *stateLockedError is returned if state is locked by someone else
+	lock(info stateLock) error
+	// unlock releases lock with ID, also lock of other process can be released (force-unlock)
+	unlock(id string) error
+	String() string
+}
+
+// stateLock describes holder of lock, fields are compatible with Terraform HTTP backend
+type stateLock struct {
+	ID        string    `json:"ID"`
+	Operation string    `json:"Operation"`
+	Who       string    `json:"Who"`
+	Created   time.Time `json:"Created"`
+}
+
+func newStateLock(operation string) stateLock {
+	id := make([]byte, 16)
+	rand.Read(id)
+	who := "unknown"
+	if u, err := user.Current(); err == nil {
+		who = u.Username
+	}
+	hostname, _ := os.Hostname()
+	return stateLock{
+		ID:        hex.EncodeToString(id),
+		Operation: operation,
+		Who:       fmt.Sprintf("%s@%s (pid %d)", who, hostname, os.Getpid()),
+		Created:   time.Now().UTC(),
+	}
+}
+
+// stateLockedError is returned if state is locked by someone else, holder is nil if it is unknown
+type stateLockedError struct {
+	location string
+	holder   *stateLock
+	// how to release stale lock
+	hint string
+}
+
+func (e *stateLockedError) Error() string {
+	msg := fmt.Sprintf("state %s is locked", e.location)
+	if e.holder != nil {
+		msg += fmt.Sprintf(" by %s (%s) since %s, lock ID %s",
+			e.holder.Who, e.holder.Operation, e.holder.Created.Format(time.RFC3339), e.holder.ID)
+	}
+	if e.hint != "" {
+		msg += ", " + e.hint
+	}
+	return msg
+}
+
+// hint of remote backends how to release stale lock
+const forceUnlockHint = "use 'shieldoo state force-unlock' if lock is stale"
+
+// newStateBackend returns backend by location, location is http:// or https:// URL,
+// s3://bucket/key or local path
+func newStateBackend(location string) (stateBackend, error) {
+	switch {
+	case strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://"):
+		return newHttpStateBackend(location)
+	case strings.HasPrefix(location, "s3://"):
+		return newS3StateBackend(location)
+	case strings.Contains(location, "://"):
+		return nil, fmt.Errorf("unsupported state location '%s', use local path, http(s)://... URL or s3://bucket/key", location)
+	}
+	return &localStateBackend{path: location}, nil
+}
+
+// localStateBackend stores state in local file, lock is held on file with .lock suffix
+type localStateBackend struct {
+	path string
+	// open lock file while lock is held
+	lockFile *os.File
+}
+
+func (b *localStateBackend) String() string {
+	return b.path
+}
+
+func (b *localStateBackend) lockPath() string {
+	return b.path + ".lock"
+}
+
+func (b *localStateBackend) read() ([]byte, error) {
+	data, err := os.ReadFile(b.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	return data, err
+}
+
+// write writes state to temporary file which replaces state file, so state file is never partially written
+func (b *localStateBackend) write(data []byte) error {
+	return writeFileAtomic(b.path, data, 0600)
+}
+
+// writeFileAtomic writes data to temporary file in the same directory and renames it to path,
+// so readers never see partially written file and the original is kept if writing fails
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Chmod(perm)
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
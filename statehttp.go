@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// httpStateBackend stores state in HTTP server with the same protocol as Terraform HTTP backend:
+// GET reads state (404 if it does not exist), POST writes it, LOCK and UNLOCK methods
+// with lock info in body lock it, locked state is reported by 423 Locked or 409 Conflict.
+// Basic authentication is used if SHIELDOO_STATE_USERNAME and SHIELDOO_STATE_PASSWORD are set.
+type httpStateBackend struct {
+	url        string
+	username   string
+	password   string
+	httpClient *http.Client
+	// ID of lock held by this process, sent with writes
+	lockId string
+}
+
+func newHttpStateBackend(location string) (*httpStateBackend, error) {
+	u, err := url.Parse(location)
+	if err != nil {
+		return nil, fmt.Errorf("invalid state URL '%s': %s", location, err)
+	}
+	if u.User != nil {
+		return nil, fmt.Errorf("credentials must not be part of state URL, use SHIELDOO_STATE_USERNAME and SHIELDOO_STATE_PASSWORD")
+	}
+	return &httpStateBackend{
+		url:        location,
+		username:   os.Getenv("SHIELDOO_STATE_USERNAME"),
+		password:   os.Getenv("SHIELDOO_STATE_PASSWORD"),
+		httpClient: &http.Client{Timeout: apiTimeout},
+	}, nil
+}
+
+func (b *httpStateBackend) String() string {
+	return b.url
+}
+
+func (b *httpStateBackend) do(method string, url string, body []byte) (*http.Response, []byte, error) {
+	req, err := http.NewRequestWithContext(apiContext(), method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if b.username != "" || b.password != "" {
+		req.SetBasicAuth(b.username, b.password)
+	}
+	resp, err := b.httpClient.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	return resp, data, err
+}
+
+func httpStateError(method string, resp *http.Response, body []byte) error {
+	return fmt.Errorf("%s failed: %s %s", method, resp.Status, strings.TrimSpace(string(body)))
+}
+
+func (b *httpStateBackend) read() ([]byte, error) {
+	resp, data, err := b.do(http.MethodGet, b.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		if len(data) == 0 {
+			return nil, nil
+		}
+		return data, nil
+	case http.StatusNotFound, http.StatusNoContent:
+		return nil, nil
+	}
+	return nil, httpStateError(http.MethodGet, resp, data)
+}
+
+func (b *httpStateBackend) write(data []byte) error {
+	u := b.url
+	if b.lockId != "" {
+		sep := "?"
+		if strings.Contains(u, "?") {
+			sep = "&"
+		}
+		u += sep + "ID=" + url.QueryEscape(b.lockId)
+	}
+	resp, body, err := b.do(http.MethodPost, u, data)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode/100 != 2 {
+		return httpStateError(http.MethodPost, resp, body)
+	}
+	return nil
+}
+
+func (b *httpStateBackend) lock(info stateLock) error {
+	data, _ := json.Marshal(info)
+	resp, body, err := b.do("LOCK", b.url, data)
+	if err != nil {
+		return err
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		b.lockId = info.ID
+		return nil
+	case http.StatusLocked, http.StatusConflict:
+		locked := &stateLockedError{location: b.url, hint: forceUnlockHint}
+		var holder stateLock
+		if json.Unmarshal(body, &holder) == nil && holder.ID != "" {
+			locked.holder = &holder
+		}
+		return locked
+	}
+	return httpStateError("LOCK", resp, body)
+}
+
+func (b *httpStateBackend) unlock(id string) error {
+	data, _ := json.Marshal(stateLock{ID: id})
+	resp, body, err := b.do("UNLOCK", b.url, data)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return httpStateError("UNLOCK", resp, body)
+	}
+	b.lockId = ""
+	return nil
+}
@@ -0,0 +1,52 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// lock holds flock on lock file, lock is released by kernel when process exits,
+// so lock can never be stale, holder is written to lock file for error messages
+func (b *localStateBackend) lock(info stateLock) error {
+	f, err := os.OpenFile(b.lockPath(), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		f.Close()
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			locked := &stateLockedError{location: b.path}
+			var holder stateLock
+			if data, err := os.ReadFile(b.lockPath()); err == nil && json.Unmarshal(data, &holder) == nil {
+				locked.holder = &holder
+			}
+			return locked
+		}
+		return err
+	}
+	data, _ := json.Marshal(info)
+	if err := f.Truncate(0); err == nil {
+		f.WriteAt(data, 0)
+	}
+	b.lockFile = f
+	return nil
+}
+
+// unlock releases flock, lock file is not deleted, because other process can wait for lock on it
+func (b *localStateBackend) unlock(id string) error {
+	if b.lockFile == nil {
+		return fmt.Errorf("local state lock is released automatically when process holding it exits")
+	}
+	b.lockFile.Truncate(0)
+	err := syscall.Flock(int(b.lockFile.Fd()), syscall.LOCK_UN)
+	if cerr := b.lockFile.Close(); err == nil {
+		err = cerr
+	}
+	b.lockFile = nil
+	return err
+}
@@ -0,0 +1,39 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+
+package main
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestFlockStateLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	first := &localStateBackend{path: path}
+	second := &localStateBackend{path: path}
+
+	info := newStateLock("apply")
+	if err := first.lock(info); err != nil {
+		t.Fatal(err)
+	}
+	// flock is held by open file, so the same process can not lock it twice
+	err := second.lock(newStateLock("apply"))
+	var locked *stateLockedError
+	if !errors.As(err, &locked) {
+		t.Fatalf("got error %v, want *stateLockedError", err)
+	}
+	if locked.holder == nil || locked.holder.ID != info.ID {
+		t.Errorf("lock holder %+v, want lock ID %s", locked.holder, info.ID)
+	}
+	if err := second.unlock(info.ID); err == nil {
+		t.Error("lock held by other file was released")
+	}
+	if err := first.unlock(info.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := second.lock(newStateLock("apply")); err != nil {
+		t.Fatalf("lock after unlock: %s", err)
+	}
+	second.unlock("")
+}
@@ -0,0 +1,48 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+)
+
+// lock creates lock file exclusively, lock file of process which was killed
+// has to be removed by force-unlock
+func (b *localStateBackend) lock(info stateLock) error {
+	f, err := os.OpenFile(b.lockPath(), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if errors.Is(err, fs.ErrExist) {
+		locked := &stateLockedError{location: b.path, hint: forceUnlockHint}
+		var holder stateLock
+		if data, err := os.ReadFile(b.lockPath()); err == nil && json.Unmarshal(data, &holder) == nil {
+			locked.holder = &holder
+		}
+		return locked
+	}
+	if err != nil {
+		return err
+	}
+	err = json.NewEncoder(f).Encode(info)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(b.lockPath())
+	}
+	return err
+}
+
+func (b *localStateBackend) unlock(id string) error {
+	var holder stateLock
+	data, err := os.ReadFile(b.lockPath())
+	if err != nil {
+		return err
+	}
+	if json.Unmarshal(data, &holder) == nil && holder.ID != id {
+		return fmt.Errorf("state %s is locked by other lock ID %s", b.path, holder.ID)
+	}
+	return os.Remove(b.lockPath())
+}
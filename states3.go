@@ -0,0 +1,254 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// s3StateBackend stores state in S3 compatible storage (location s3://bucket/key),
+// lock is object with .lock suffix created by conditional write (If-None-Match: *).
+// Credentials are read from AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN,
+// region from AWS_REGION or AWS_DEFAULT_REGION, SHIELDOO_S3_ENDPOINT sets endpoint of
+// other S3 compatible storage (for example MinIO), path-style requests are used for it.
+type s3StateBackend struct {
+	bucket       string
+	key          string
+	endpoint     string
+	pathStyle    bool
+	region       string
+	accessKey    string
+	secretKey    string
+	sessionToken string
+	httpClient   *http.Client
+}
+
+func newS3StateBackend(location string) (*s3StateBackend, error) {
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(location, "s3://"), "/")
+	if bucket == "" || key == "" {
+		return nil, fmt.Errorf("invalid state location '%s', expected s3://bucket/key", location)
+	}
+	b := &s3StateBackend{
+		bucket:       bucket,
+		key:          key,
+		region:       os.Getenv("AWS_REGION"),
+		accessKey:    os.Getenv("AWS_ACCESS_KEY_ID"),
+		secretKey:    os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		sessionToken: os.Getenv("AWS_SESSION_TOKEN"),
+		httpClient:   &http.Client{Timeout: apiTimeout},
+	}
+	if b.region == "" {
+		b.region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	if b.region == "" {
+		b.region = "us-east-1"
+	}
+	if b.accessKey == "" || b.secretKey == "" {
+		return nil, fmt.Errorf("S3 state requires AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY")
+	}
+	if endpoint := os.Getenv("SHIELDOO_S3_ENDPOINT"); endpoint != "" {
+		b.endpoint = strings.TrimSuffix(endpoint, "/")
+		b.pathStyle = true
+	} else {
+		b.endpoint = fmt.Sprintf("https://%s.s3.%s.amazonaws.com", bucket, b.region)
+	}
+	return b, nil
+}
+
+func (b *s3StateBackend) String() string {
+	return "s3://" + b.bucket + "/" + b.key
+}
+
+// objectUrl returns URL of object, key is encoded the same way as in canonical request of signature
+func (b *s3StateBackend) objectUrl(key string) string {
+	path := "/" + s3Escape(key)
+	if b.pathStyle {
+		path = "/" + s3Escape(b.bucket) + path
+	}
+	return b.endpoint + path
+}
+
+// s3Escape encodes all characters except unreserved ones and slash
+func s3Escape(s string) string {
+	var sb strings.Builder
+	for _, c := range []byte(s) {
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || strings.IndexByte("-._~/", c) >= 0 {
+			sb.WriteByte(c)
+		} else {
+			fmt.Fprintf(&sb, "%%%02X", c)
+		}
+	}
+	return sb.String()
+}
+
+func hmacSha256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func sha256Hex(data []byte) string {
+	h := sha256.Sum256(data)
+	return hex.EncodeToString(h[:])
+}
+
+// signS3Request signs request by AWS Signature Version 4, host and all headers set before signing are signed
+func signS3Request(req *http.Request, payload []byte, region string, accessKey string, secretKey string, sessionToken string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	payloadHash := sha256Hex(payload)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", sessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for k, v := range req.Header {
+		headers[strings.ToLower(k)] = strings.TrimSpace(strings.Join(v, ","))
+	}
+	var names []string
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, k := range names {
+		canonicalHeaders.WriteString(k + ":" + headers[k] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.Query().Encode(),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := date + "/" + region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+	key := hmacSha256([]byte("AWS4"+secretKey), date)
+	key = hmacSha256(key, region)
+	key = hmacSha256(key, "s3")
+	key = hmacSha256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSha256(key, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		accessKey, scope, signedHeaders, signature))
+}
+
+func (b *s3StateBackend) do(method string, key string, body []byte, headers map[string]string) (*http.Response, []byte, error) {
+	u, err := url.Parse(b.objectUrl(key))
+	if err != nil {
+		return nil, nil, err
+	}
+	req, err := http.NewRequestWithContext(apiContext(), method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, nil, err
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	signS3Request(req, body, b.region, b.accessKey, b.secretKey, b.sessionToken, time.Now())
+	resp, err := b.httpClient.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	return resp, data, err
+}
+
+func s3Error(method string, resp *http.Response, body []byte) error {
+	return fmt.Errorf("%s failed: %s %s", method, resp.Status, strings.TrimSpace(string(body)))
+}
+
+func (b *s3StateBackend) lockKey() string {
+	return b.key + ".lock"
+}
+
+func (b *s3StateBackend) get(key string) ([]byte, error) {
+	resp, data, err := b.do(http.MethodGet, key, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return data, nil
+	case http.StatusNotFound:
+		return nil, nil
+	}
+	return nil, s3Error(http.MethodGet, resp, data)
+}
+
+func (b *s3StateBackend) read() ([]byte, error) {
+	return b.get(b.key)
+}
+
+func (b *s3StateBackend) write(data []byte) error {
+	resp, body, err := b.do(http.MethodPut, b.key, data, map[string]string{"Content-Type": "application/json"})
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode/100 != 2 {
+		return s3Error(http.MethodPut, resp, body)
+	}
+	return nil
+}
+
+func (b *s3StateBackend) lock(info stateLock) error {
+	data, _ := json.Marshal(info)
+	resp, body, err := b.do(http.MethodPut, b.lockKey(), data, map[string]string{
+		"Content-Type":  "application/json",
+		"If-None-Match": "*",
+	})
+	if err != nil {
+		return err
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	// lock object already exists or it is just being created by other process
+	case http.StatusPreconditionFailed, http.StatusConflict:
+		locked := &stateLockedError{location: b.String(), hint: forceUnlockHint}
+		var holder stateLock
+		if data, err := b.get(b.lockKey()); err == nil && json.Unmarshal(data, &holder) == nil && holder.ID != "" {
+			locked.holder = &holder
+		}
+		return locked
+	}
+	return s3Error(http.MethodPut, resp, body)
+}
+
+// unlock deletes lock object if it is held by lock ID
+func (b *s3StateBackend) unlock(id string) error {
+	data, err := b.get(b.lockKey())
+	if err != nil {
+		return err
+	}
+	if data == nil {
+		return fmt.Errorf("state %s is not locked", b)
+	}
+	var holder stateLock
+	if json.Unmarshal(data, &holder) == nil && holder.ID != id {
+		return fmt.Errorf("state %s is locked by other lock ID %s", b, holder.ID)
+	}
+	resp, body, err := b.do(http.MethodDelete, b.lockKey(), nil, nil)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode/100 != 2 {
+		return s3Error(http.MethodDelete, resp, body)
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var varNameRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// loadVarFile reads variables from YAML file with mapping of names to scalar values,
+// variables from later files override variables from previous files
+func (l *manifestLoader) loadVarFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var vars map[string]string
+	if err := yaml.Unmarshal(data, &vars); err != nil {
+		return fmt.Errorf("%s: %s", path, err)
+	}
+	for k, v := range vars {
+		if !varNameRegexp.MatchString(k) {
+			return fmt.Errorf("%s: invalid variable name '%s'", path, k)
+		}
+		l.vars[k] = v
+	}
+	return nil
+}
+
+// lookup returns value of variable from var files or from environment
+func (l *manifestLoader) lookup(name string) (string, bool) {
+	if v, ok := l.vars[name]; ok {
+		return v, true
+	}
+	return os.LookupEnv(name)
+}
+
+// substitute replaces ${NAME} and ${NAME:-default} in scalar values of parsed YAML document by values
+// of variables, $${ is written as ${. Values of variables can not change structure of manifest, because
+// they are substituted after parsing, keys and comments are not changed.
+// All undefined variables are reported in one error.
+func (l *manifestLoader) substitute(path string, node *yaml.Node) error {
+	var errs []string
+	l.substituteNode(path, node, &errs)
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		return fmt.Errorf("%s", errs[0])
+	}
+	return fmt.Errorf("invalid variable references:\n  %s", strings.Join(errs, "\n  "))
+}
+
+func (l *manifestLoader) substituteNode(path string, node *yaml.Node, errs *[]string) {
+	switch node.Kind {
+	case yaml.DocumentNode, yaml.SequenceNode:
+		for _, n := range node.Content {
+			l.substituteNode(path, n, errs)
+		}
+	case yaml.MappingNode:
+		for i := 1; i < len(node.Content); i += 2 {
+			l.substituteNode(path, node.Content[i], errs)
+		}
+	case yaml.ScalarNode:
+		value := l.substituteValue(fmt.Sprintf("%s:%d", path, node.Line), node.Value, errs)
+		if value == node.Value {
+			return
+		}
+		node.Value = value
+		// type of plain scalar is resolved from substituted value (port: ${PORT} is number)
+		if node.Style == 0 {
+			node.Tag = ""
+		}
+	}
+	// alias refers to node which is already substituted
+}
+
+func (l *manifestLoader) substituteValue(pos string, value string, errs *[]string) string {
+	var b strings.Builder
+	for {
+		start := strings.Index(value, "${")
+		if start < 0 {
+			b.WriteString(value)
+			break
+		}
+		if start > 0 && value[start-1] == '$' {
+			b.WriteString(value[:start-1] + "${")
+			value = value[start+2:]
+			continue
+		}
+		b.WriteString(value[:start])
+		end := strings.Index(value[start:], "}")
+		if end < 0 {
+			*errs = append(*errs, fmt.Sprintf("%s: unterminated variable reference", pos))
+			b.WriteString(value[start:])
+			break
+		}
+		expr := value[start+2 : start+end]
+		value = value[start+end+1:]
+		name, def, hasDefault := strings.Cut(expr, ":-")
+		if !varNameRegexp.MatchString(name) {
+			*errs = append(*errs, fmt.Sprintf("%s: invalid variable name '%s'", pos, name))
+			continue
+		}
+		v, ok := l.lookup(name)
+		if !ok {
+			if !hasDefault {
+				*errs = append(*errs, fmt.Sprintf("%s: variable '%s' is not defined", pos, name))
+				continue
+			}
+			v = def
+		}
+		b.WriteString(v)
+		l.substituted = true
+	}
+	return b.String()
+}
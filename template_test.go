@@ -0,0 +1,121 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestManifestVariables(t *testing.T) {
+	t.Setenv("ENV", "prod")
+	t.Setenv("PORT", "8443")
+	// value of variable can not add keys or items to manifest
+	t.Setenv("INJECTED", "web\n    rulesIn:\n      - any;any;any")
+	t.Setenv("FLOW", "[any;any;any]")
+	tests := []struct {
+		name     string
+		manifest string
+		want     Firewall
+		wantErr  string
+	}{
+		{
+			name:     "plain and quoted values",
+			manifest: "firewalls:\n  - name: web-${ENV}\n    rulesIn:\n      - \"tcp;${PORT};any\"\n",
+			want:     Firewall{Name: "web-prod", RulesIn: []FirewallRule{{Protocol: "tcp", Port: "8443", Host: "any"}}},
+		},
+		{
+			name:     "default value",
+			manifest: "firewalls:\n  - name: ${NAME:-web}\n",
+			want:     Firewall{Name: "web"},
+		},
+		{
+			name:     "escaped reference",
+			manifest: "firewalls:\n  - name: web\n    rulesIn:\n      - protocol: tcp\n        port: \"22\"\n        host: group\n        groups:\n          - name: $${ENV}\n",
+			want:     Firewall{Name: "web", RulesIn: []FirewallRule{{Protocol: "tcp", Port: "22", Host: "group", Groups: []Group{{Name: "${ENV}"}}}}},
+		},
+		{
+			name:     "comments are not substituted",
+			manifest: "# ${UNDEFINED}\nfirewalls:\n  - name: web # was ${OLD}\n    rulesIn:\n      - protocol: tcp\n        port: 80 # was ${OLD}\n        host: any\n",
+			want:     Firewall{Name: "web", RulesIn: []FirewallRule{{Protocol: "tcp", Port: "80", Host: "any"}}},
+		},
+		{
+			name:     "value with new lines",
+			manifest: "firewalls:\n  - name: ${INJECTED}\n",
+			want:     Firewall{Name: "web\n    rulesIn:\n      - any;any;any"},
+		},
+		{
+			name:     "value with flow sequence",
+			manifest: "firewalls:\n  - name: web\n    rulesIn: ${FLOW}\n",
+			wantErr:  "cannot unmarshal",
+		},
+		{
+			name:     "undefined variables",
+			manifest: "firewalls:\n  - name: ${UNDEFINED}\n    rulesIn:\n      - tcp;${OTHER};any\n",
+			wantErr:  "manifest.yaml:2: variable 'UNDEFINED' is not defined\n  ",
+		},
+		{
+			name:     "unterminated reference",
+			manifest: "firewalls:\n  - name: web-${ENV\n",
+			wantErr:  "manifest.yaml:2: unterminated variable reference",
+		},
+		{
+			name:     "keys are not substituted",
+			manifest: "firewalls:\n  - ${KEY:-name}: web\n",
+			wantErr:  "unknown field '${KEY:-name}'",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "manifest.yaml")
+			if err := os.WriteFile(path, []byte(tt.manifest), 0644); err != nil {
+				t.Fatal(err)
+			}
+			l, err := newManifestLoader(nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			m, err := l.load([]string{path})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(m.Firewalls) != 1 || !reflect.DeepEqual(m.Firewalls[0].Firewall, tt.want) {
+				t.Errorf("got %+v, want %+v", m.Firewalls, tt.want)
+			}
+		})
+	}
+}
+
+// number in plain value is decoded as number, so variable can be used for numeric fields
+func TestManifestVariablesNumber(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "manifest.yaml")
+	manifest := "servers:\n  - name: web-1\n    firewall:\n      name: web\n    listeners:\n      - listenPort: ${PORT}\n        protocol: tcp\n        forwardPort: ${PORT}\n        forwardHost: localhost\n"
+	if err := os.WriteFile(path, []byte(manifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+	varFile := filepath.Join(t.TempDir(), "vars.yaml")
+	if err := os.WriteFile(varFile, []byte("PORT: 8080\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	l, err := newManifestLoader([]string{varFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := l.load([]string{path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := m.Servers[0].Listeners[0]; got.ListenPort != 8080 || got.ForwardPort != 8080 {
+		t.Errorf("got listener %+v, want ports 8080", got)
+	}
+	if !l.substituted {
+		t.Error("substitution is not reported")
+	}
+}
@@ -0,0 +1,276 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/shieldoo/shieldoo-cli/client"
+)
+
+// data sources find existing objects by ID or name, all other attributes are computed
+
+type dataSourceClient struct {
+	client *client.Client
+}
+
+func (d *dataSourceClient) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	c, ok := req.ProviderData.(*client.Client)
+	if !ok {
+		resp.Diagnostics.AddError("Unexpected provider data", fmt.Sprintf("Expected *client.Client, got %T", req.ProviderData))
+		return
+	}
+	d.client = c
+}
+
+func lookupAttribute(description string) schema.StringAttribute {
+	return schema.StringAttribute{
+		Description: description,
+		Optional:    true,
+		Computed:    true,
+	}
+}
+
+func computedString(description string) schema.StringAttribute {
+	return schema.StringAttribute{
+		Description: description,
+		Computed:    true,
+	}
+}
+
+func computedStringList(description string) schema.ListAttribute {
+	return schema.ListAttribute{
+		Description: description,
+		Computed:    true,
+		ElementType: types.StringType,
+	}
+}
+
+// group
+
+type groupDataSource struct {
+	dataSourceClient
+}
+
+func newGroupDataSource() datasource.DataSource {
+	return &groupDataSource{}
+}
+
+func (d *groupDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_group"
+}
+
+func (d *groupDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Finds group by ID, name or object ID.",
+		Attributes: map[string]schema.Attribute{
+			"id":          lookupAttribute("Group ID."),
+			"name":        lookupAttribute("Group name."),
+			"object_id":   lookupAttribute("Object ID of group in identity provider."),
+			"description": computedString("Group description."),
+		},
+	}
+}
+
+func (d *groupDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var config groupModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	var group *client.Group
+	var err error
+	switch {
+	case !config.Id.IsNull():
+		group, err = d.client.GetGroup(ctx, config.Id.ValueString())
+	case !config.Name.IsNull():
+		group, err = d.client.GetGroupByName(ctx, config.Name.ValueString())
+	case !config.ObjectId.IsNull():
+		var groups []client.Group
+		groups, err = d.client.ListGroups(ctx)
+		for i := range groups {
+			if groups[i].ObjectId == config.ObjectId.ValueString() {
+				group = &groups[i]
+				break
+			}
+		}
+	default:
+		resp.Diagnostics.AddError("Missing group lookup attribute", "One of id, name or object_id must be set.")
+		return
+	}
+	if err == nil && group == nil || client.IsNotFound(err) {
+		resp.Diagnostics.AddError("Group not found", "There is no group matching given attributes.")
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError("Cannot read group", err.Error())
+		return
+	}
+	config.fromGroup(*group)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &config)...)
+}
+
+// firewall
+
+type firewallDataSource struct {
+	dataSourceClient
+}
+
+func newFirewallDataSource() datasource.DataSource {
+	return &firewallDataSource{}
+}
+
+func (d *firewallDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_firewall"
+}
+
+func computedFirewallRules(description string) schema.ListNestedAttribute {
+	return schema.ListNestedAttribute{
+		Description: description,
+		Computed:    true,
+		NestedObject: schema.NestedAttributeObject{
+			Attributes: map[string]schema.Attribute{
+				"protocol":  computedString("Protocol."),
+				"port":      computedString("Port or port range."),
+				"host":      computedString("Host: any or group."),
+				"group_ids": computedStringList("IDs of groups if host is group."),
+			},
+		},
+	}
+}
+
+func (d *firewallDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Finds firewall by ID or name.",
+		Attributes: map[string]schema.Attribute{
+			"id":        lookupAttribute("Firewall ID."),
+			"name":      lookupAttribute("Firewall name."),
+			"rules_in":  computedFirewallRules("Inbound rules."),
+			"rules_out": computedFirewallRules("Outbound rules."),
+		},
+	}
+}
+
+func (d *firewallDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var config firewallModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	var fw *client.Firewall
+	var err error
+	switch {
+	case !config.Id.IsNull():
+		fw, err = d.client.GetFirewall(ctx, config.Id.ValueString())
+	case !config.Name.IsNull():
+		fw, err = d.client.GetFirewallByName(ctx, config.Name.ValueString())
+	default:
+		resp.Diagnostics.AddError("Missing firewall lookup attribute", "One of id or name must be set.")
+		return
+	}
+	if err == nil && fw == nil || client.IsNotFound(err) {
+		resp.Diagnostics.AddError("Firewall not found", "There is no firewall matching given attributes.")
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError("Cannot read firewall", err.Error())
+		return
+	}
+	config.fromFirewall(*fw)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &config)...)
+}
+
+// server
+
+type serverDataSource struct {
+	dataSourceClient
+}
+
+func newServerDataSource() datasource.DataSource {
+	return &serverDataSource{}
+}
+
+func (d *serverDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_server"
+}
+
+func (d *serverDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Finds server by ID or name.",
+		Attributes: map[string]schema.Attribute{
+			"id":          lookupAttribute("Server ID."),
+			"name":        lookupAttribute("Server name."),
+			"firewall_id": computedString("ID of firewall assigned to server."),
+			"group_ids":   computedStringList("IDs of groups whose members can access server."),
+			"listeners": schema.ListNestedAttribute{
+				Description: "Ports forwarded by server to other hosts.",
+				Computed:    true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"listen_port":  schema.Int64Attribute{Description: "Port listening on server.", Computed: true},
+						"protocol":     computedString("Protocol."),
+						"forward_port": schema.Int64Attribute{Description: "Port of target host.", Computed: true},
+						"forward_host": computedString("Target host."),
+						"description":  computedString("Listener description."),
+					},
+				},
+			},
+			"autoupdate":  schema.BoolAttribute{Description: "Automatic update of shieldoo client.", Computed: true},
+			"ip_address":  computedString("IP address of server in shieldoo network."),
+			"description": computedString("Server description."),
+			"os_update_policy": schema.SingleNestedAttribute{
+				Description: "Automatic update of server operating system.",
+				Computed:    true,
+				Attributes: map[string]schema.Attribute{
+					"enabled":                     schema.BoolAttribute{Computed: true},
+					"security_autoupdate_enabled": schema.BoolAttribute{Computed: true},
+					"all_autoupdate_enabled":      schema.BoolAttribute{Computed: true},
+					"restart_after_update":        schema.BoolAttribute{Computed: true},
+					"update_hour":                 schema.Int64Attribute{Computed: true},
+				},
+			},
+			"configuration": schema.StringAttribute{
+				Description: "Configuration of shieldoo client for the server.",
+				Computed:    true,
+				Sensitive:   true,
+			},
+		},
+	}
+}
+
+func (d *serverDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var config serverModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	var s *client.Server
+	var err error
+	switch {
+	case !config.Id.IsNull():
+		s, err = d.client.GetServer(ctx, config.Id.ValueString())
+	case !config.Name.IsNull():
+		s, err = d.client.GetServerByName(ctx, config.Name.ValueString())
+	default:
+		resp.Diagnostics.AddError("Missing server lookup attribute", "One of id or name must be set.")
+		return
+	}
+	if err == nil && s == nil || client.IsNotFound(err) {
+		resp.Diagnostics.AddError("Server not found", "There is no server matching given attributes.")
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError("Cannot read server", err.Error())
+		return
+	}
+	// all attributes are computed, description and policy are set even if empty
+	config.Description = types.StringValue("")
+	config.OSUpdatePolicy = &osUpdatePolicyModel{}
+	config.fromServer(*s)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &config)...)
+}
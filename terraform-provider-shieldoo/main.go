@@ -0,0 +1,28 @@
+// Terraform provider for shieldoo servers and firewalls, it uses the same API client
+// and models as shieldoo CLI.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+
+	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+)
+
+// version is set by build (-ldflags "-X main.version=...")
+var version = "dev"
+
+func main() {
+	var debug bool
+	flag.BoolVar(&debug, "debug", false, "Start provider in debug mode for debuggers like delve")
+	flag.Parse()
+
+	err := providerserver.Serve(context.Background(), newProvider(version), providerserver.ServeOpts{
+		Address: "registry.terraform.io/shieldoo/shieldoo",
+		Debug:   debug,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+}
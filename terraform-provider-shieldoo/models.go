@@ -0,0 +1,212 @@
+package main
+
+import (
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/shieldoo/shieldoo-cli/client"
+)
+
+// Terraform models of API models, groups are referenced by ID (use shieldoo_group data source to find group by name)
+
+type firewallRuleModel struct {
+	Protocol string   `tfsdk:"protocol"`
+	Port     string   `tfsdk:"port"`
+	Host     string   `tfsdk:"host"`
+	GroupIds []string `tfsdk:"group_ids"`
+}
+
+type firewallModel struct {
+	Id       types.String        `tfsdk:"id"`
+	Name     types.String        `tfsdk:"name"`
+	RulesIn  []firewallRuleModel `tfsdk:"rules_in"`
+	RulesOut []firewallRuleModel `tfsdk:"rules_out"`
+}
+
+type listenerModel struct {
+	ListenPort  int64        `tfsdk:"listen_port"`
+	Protocol    string       `tfsdk:"protocol"`
+	ForwardPort int64        `tfsdk:"forward_port"`
+	ForwardHost string       `tfsdk:"forward_host"`
+	Description types.String `tfsdk:"description"`
+}
+
+type osUpdatePolicyModel struct {
+	Enabled                   bool  `tfsdk:"enabled"`
+	SecurityAutoupdateEnabled bool  `tfsdk:"security_autoupdate_enabled"`
+	AllAutoupdateEnabled      bool  `tfsdk:"all_autoupdate_enabled"`
+	RestartAfterUpdate        bool  `tfsdk:"restart_after_update"`
+	UpdateHour                int64 `tfsdk:"update_hour"`
+}
+
+type serverModel struct {
+	Id             types.String         `tfsdk:"id"`
+	Name           types.String         `tfsdk:"name"`
+	FirewallId     types.String         `tfsdk:"firewall_id"`
+	GroupIds       []string             `tfsdk:"group_ids"`
+	Listeners      []listenerModel      `tfsdk:"listeners"`
+	Autoupdate     types.Bool           `tfsdk:"autoupdate"`
+	IpAddress      types.String         `tfsdk:"ip_address"`
+	Description    types.String         `tfsdk:"description"`
+	OSUpdatePolicy *osUpdatePolicyModel `tfsdk:"os_update_policy"`
+	Configuration  types.String         `tfsdk:"configuration"`
+}
+
+type groupModel struct {
+	Id          types.String `tfsdk:"id"`
+	Name        types.String `tfsdk:"name"`
+	ObjectId    types.String `tfsdk:"object_id"`
+	Description types.String `tfsdk:"description"`
+}
+
+// optionalString returns null for empty value if prior value is null,
+// so optional attribute which is not configured stays null
+func optionalString(v string, prior types.String) types.String {
+	if v == "" && prior.IsNull() {
+		return types.StringNull()
+	}
+	return types.StringValue(v)
+}
+
+// optionalList returns empty list instead of null if prior value is empty list
+func optionalList[T any](values []T, prior []T) []T {
+	if len(values) == 0 && prior != nil {
+		return []T{}
+	}
+	return values
+}
+
+func groupRefs(ids []string) []client.Group {
+	var ret []client.Group
+	for _, id := range ids {
+		ret = append(ret, client.Group{Id: id})
+	}
+	return ret
+}
+
+func groupIds(groups []client.Group) []string {
+	var ret []string
+	for _, g := range groups {
+		ret = append(ret, g.Id)
+	}
+	return ret
+}
+
+func toFirewallRules(rules []firewallRuleModel) []client.FirewallRule {
+	ret := []client.FirewallRule{}
+	for _, r := range rules {
+		ret = append(ret, client.FirewallRule{
+			Protocol: r.Protocol,
+			Port:     r.Port,
+			Host:     r.Host,
+			Groups:   groupRefs(r.GroupIds),
+		})
+	}
+	return ret
+}
+
+func fromFirewallRules(rules []client.FirewallRule, prior []firewallRuleModel) []firewallRuleModel {
+	var ret []firewallRuleModel
+	for i, r := range rules {
+		var priorGroups []string
+		if i < len(prior) {
+			priorGroups = prior[i].GroupIds
+		}
+		ret = append(ret, firewallRuleModel{
+			Protocol: r.Protocol,
+			Port:     r.Port,
+			Host:     r.Host,
+			GroupIds: optionalList(groupIds(r.Groups), priorGroups),
+		})
+	}
+	return optionalList(ret, prior)
+}
+
+func (m *firewallModel) toFirewall() client.Firewall {
+	return client.Firewall{
+		Id:       m.Id.ValueString(),
+		Name:     m.Name.ValueString(),
+		RulesIn:  toFirewallRules(m.RulesIn),
+		RulesOut: toFirewallRules(m.RulesOut),
+	}
+}
+
+func (m *firewallModel) fromFirewall(fw client.Firewall) {
+	m.Id = types.StringValue(fw.Id)
+	m.Name = types.StringValue(fw.Name)
+	m.RulesIn = fromFirewallRules(fw.RulesIn, m.RulesIn)
+	m.RulesOut = fromFirewallRules(fw.RulesOut, m.RulesOut)
+}
+
+func (m *serverModel) toServer() client.Server {
+	s := client.Server{
+		Id:          m.Id.ValueString(),
+		Name:        m.Name.ValueString(),
+		Firewall:    client.Firewall{Id: m.FirewallId.ValueString()},
+		Groups:      groupRefs(m.GroupIds),
+		Autoupdate:  m.Autoupdate.ValueBool(),
+		IpAddress:   m.IpAddress.ValueString(),
+		Description: m.Description.ValueString(),
+	}
+	for _, l := range m.Listeners {
+		s.Listeners = append(s.Listeners, client.Listener{
+			ListenPort:  int(l.ListenPort),
+			Protocol:    l.Protocol,
+			ForwardPort: int(l.ForwardPort),
+			ForwardHost: l.ForwardHost,
+			Description: l.Description.ValueString(),
+		})
+	}
+	if p := m.OSUpdatePolicy; p != nil {
+		s.OSUpdatePolicy = client.ServerOSAutoupdatePolicy{
+			Enabled:                   p.Enabled,
+			SecurityAutoupdateEnabled: p.SecurityAutoupdateEnabled,
+			AllAutoupdateEnabled:      p.AllAutoupdateEnabled,
+			RestartAfterUpdate:        p.RestartAfterUpdate,
+			UpdateHour:                int(p.UpdateHour),
+		}
+	}
+	return s
+}
+
+func (m *serverModel) fromServer(s client.Server) {
+	m.Id = types.StringValue(s.Id)
+	m.Name = types.StringValue(s.Name)
+	m.FirewallId = types.StringValue(s.Firewall.Id)
+	m.GroupIds = optionalList(groupIds(s.Groups), m.GroupIds)
+	var listeners []listenerModel
+	for i, l := range s.Listeners {
+		priorDescription := types.StringNull()
+		if i < len(m.Listeners) {
+			priorDescription = m.Listeners[i].Description
+		}
+		listeners = append(listeners, listenerModel{
+			ListenPort:  int64(l.ListenPort),
+			Protocol:    l.Protocol,
+			ForwardPort: int64(l.ForwardPort),
+			ForwardHost: l.ForwardHost,
+			Description: optionalString(l.Description, priorDescription),
+		})
+	}
+	m.Listeners = optionalList(listeners, m.Listeners)
+	m.Autoupdate = types.BoolValue(s.Autoupdate)
+	m.IpAddress = types.StringValue(s.IpAddress)
+	m.Description = optionalString(s.Description, m.Description)
+	// policy which is not configured stays null, if it has default values
+	p := s.OSUpdatePolicy
+	if m.OSUpdatePolicy != nil || p != (client.ServerOSAutoupdatePolicy{}) {
+		m.OSUpdatePolicy = &osUpdatePolicyModel{
+			Enabled:                   p.Enabled,
+			SecurityAutoupdateEnabled: p.SecurityAutoupdateEnabled,
+			AllAutoupdateEnabled:      p.AllAutoupdateEnabled,
+			RestartAfterUpdate:        p.RestartAfterUpdate,
+			UpdateHour:                int64(p.UpdateHour),
+		}
+	}
+	m.Configuration = types.StringValue(s.Configuration)
+}
+
+func (m *groupModel) fromGroup(g client.Group) {
+	m.Id = types.StringValue(g.Id)
+	m.Name = types.StringValue(g.Name)
+	m.ObjectId = types.StringValue(g.ObjectId)
+	m.Description = types.StringValue(g.Description)
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/shieldoo/shieldoo-cli/client"
+)
+
+// Optional attributes which are not configured have to stay null after read, attributes configured
+// as empty value have to stay empty, otherwise terraform reports inconsistent result after apply.
+
+func TestFromServerDescription(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		prior types.String
+		want  types.String
+	}{
+		{name: "not configured", value: "", prior: types.StringNull(), want: types.StringNull()},
+		{name: "configured empty", value: "", prior: types.StringValue(""), want: types.StringValue("")},
+		{name: "set outside of terraform", value: "db", prior: types.StringNull(), want: types.StringValue("db")},
+		{name: "removed outside of terraform", value: "", prior: types.StringValue("db"), want: types.StringValue("")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := serverModel{
+				Description: tt.prior,
+				Listeners:   []listenerModel{{Description: tt.prior}},
+			}
+			m.fromServer(client.Server{Description: tt.value, Listeners: []client.Listener{{Description: tt.value}}})
+			if !m.Description.Equal(tt.want) {
+				t.Errorf("got description %s, want %s", m.Description, tt.want)
+			}
+			if !m.Listeners[0].Description.Equal(tt.want) {
+				t.Errorf("got listener description %s, want %s", m.Listeners[0].Description, tt.want)
+			}
+		})
+	}
+	// description of new listener is compared with null
+	m := serverModel{Description: types.StringNull()}
+	m.fromServer(client.Server{Listeners: []client.Listener{{ListenPort: 80}}})
+	if !m.Listeners[0].Description.IsNull() {
+		t.Errorf("got listener description %s, want null", m.Listeners[0].Description)
+	}
+}
+
+func TestFromServerGroupIds(t *testing.T) {
+	tests := []struct {
+		name   string
+		groups []client.Group
+		prior  []string
+		want   []string
+	}{
+		{name: "not configured", prior: nil, want: nil},
+		{name: "configured empty", prior: []string{}, want: []string{}},
+		{name: "groups", groups: []client.Group{{Id: "g:1"}, {Id: "g:2"}}, prior: nil, want: []string{"g:1", "g:2"}},
+		{name: "removed outside of terraform", prior: []string{"g:1"}, want: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := serverModel{GroupIds: tt.prior, Description: types.StringNull()}
+			m.fromServer(client.Server{Groups: tt.groups})
+			if !reflect.DeepEqual(m.GroupIds, tt.want) {
+				t.Errorf("got %#v, want %#v", m.GroupIds, tt.want)
+			}
+		})
+	}
+}
+
+func TestFromServerOSUpdatePolicy(t *testing.T) {
+	policy := client.ServerOSAutoupdatePolicy{Enabled: true, UpdateHour: 3}
+	tests := []struct {
+		name   string
+		policy client.ServerOSAutoupdatePolicy
+		prior  *osUpdatePolicyModel
+		want   *osUpdatePolicyModel
+	}{
+		{name: "not configured with defaults", prior: nil, want: nil},
+		{name: "configured with defaults", prior: &osUpdatePolicyModel{}, want: &osUpdatePolicyModel{}},
+		{name: "set outside of terraform", policy: policy, prior: nil, want: &osUpdatePolicyModel{Enabled: true, UpdateHour: 3}},
+		{name: "configured", policy: policy, prior: &osUpdatePolicyModel{Enabled: true}, want: &osUpdatePolicyModel{Enabled: true, UpdateHour: 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := serverModel{OSUpdatePolicy: tt.prior, Description: types.StringNull()}
+			m.fromServer(client.Server{OSUpdatePolicy: tt.policy})
+			if !reflect.DeepEqual(m.OSUpdatePolicy, tt.want) {
+				t.Errorf("got %+v, want %+v", m.OSUpdatePolicy, tt.want)
+			}
+		})
+	}
+}
+
+func TestFromFirewallRules(t *testing.T) {
+	admins := []client.Group{{Id: "g:1"}}
+	tests := []struct {
+		name  string
+		rules []client.FirewallRule
+		prior []firewallRuleModel
+		want  []firewallRuleModel
+	}{
+		{name: "not configured", prior: nil, want: nil},
+		{name: "configured empty", prior: []firewallRuleModel{}, want: []firewallRuleModel{}},
+		{
+			name:  "rule without groups not configured",
+			rules: []client.FirewallRule{{Protocol: "tcp", Port: "443", Host: "any"}},
+			prior: nil,
+			want:  []firewallRuleModel{{Protocol: "tcp", Port: "443", Host: "any"}},
+		},
+		{
+			name:  "rule with empty groups",
+			rules: []client.FirewallRule{{Protocol: "tcp", Port: "443", Host: "any"}},
+			prior: []firewallRuleModel{{Protocol: "tcp", Port: "443", Host: "any", GroupIds: []string{}}},
+			want:  []firewallRuleModel{{Protocol: "tcp", Port: "443", Host: "any", GroupIds: []string{}}},
+		},
+		{
+			name: "groups compared with rule at the same position",
+			rules: []client.FirewallRule{
+				{Protocol: "tcp", Port: "22", Host: "group", Groups: admins},
+				{Protocol: "tcp", Port: "443", Host: "any"},
+				{Protocol: "icmp", Port: "any", Host: "any"},
+			},
+			prior: []firewallRuleModel{
+				{Protocol: "tcp", Port: "22", Host: "group", GroupIds: []string{"g:1"}},
+				{Protocol: "tcp", Port: "443", Host: "any", GroupIds: []string{}},
+			},
+			want: []firewallRuleModel{
+				{Protocol: "tcp", Port: "22", Host: "group", GroupIds: []string{"g:1"}},
+				{Protocol: "tcp", Port: "443", Host: "any", GroupIds: []string{}},
+				{Protocol: "icmp", Port: "any", Host: "any"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := fromFirewallRules(tt.rules, tt.prior)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"os"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/provider"
+	"github.com/hashicorp/terraform-plugin-framework/provider/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/shieldoo/shieldoo-cli/client"
+)
+
+type shieldooProvider struct {
+	version string
+}
+
+type providerModel struct {
+	Uri    types.String `tfsdk:"uri"`
+	ApiKey types.String `tfsdk:"api_key"`
+}
+
+func newProvider(version string) func() provider.Provider {
+	return func() provider.Provider {
+		return &shieldooProvider{version: version}
+	}
+}
+
+func (p *shieldooProvider) Metadata(ctx context.Context, req provider.MetadataRequest, resp *provider.MetadataResponse) {
+	resp.TypeName = "shieldoo"
+	resp.Version = p.version
+}
+
+func (p *shieldooProvider) Schema(ctx context.Context, req provider.SchemaRequest, resp *provider.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Manages shieldoo servers and firewalls.",
+		Attributes: map[string]schema.Attribute{
+			"uri": schema.StringAttribute{
+				Description: "URI of shieldoo instance, SHIELDOO_URI environment variable is used if not set.",
+				Optional:    true,
+			},
+			"api_key": schema.StringAttribute{
+				Description: "API key from shieldoo admin portal, SHIELDOO_APIKEY environment variable is used if not set.",
+				Optional:    true,
+				Sensitive:   true,
+			},
+		},
+	}
+}
+
+func (p *shieldooProvider) Configure(ctx context.Context, req provider.ConfigureRequest, resp *provider.ConfigureResponse) {
+	var config providerModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	uri := config.Uri.ValueString()
+	if uri == "" {
+		uri = os.Getenv("SHIELDOO_URI")
+	}
+	apiKey := config.ApiKey.ValueString()
+	if apiKey == "" {
+		apiKey = os.Getenv("SHIELDOO_APIKEY")
+	}
+	if uri == "" {
+		resp.Diagnostics.AddAttributeError(path.Root("uri"), "Missing shieldoo URI",
+			"Set uri in provider configuration or SHIELDOO_URI environment variable.")
+	}
+	if apiKey == "" {
+		resp.Diagnostics.AddAttributeError(path.Root("api_key"), "Missing shieldoo API key",
+			"Set api_key in provider configuration or SHIELDOO_APIKEY environment variable.")
+	}
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	c := client.New(uri, apiKey)
+	resp.DataSourceData = c
+	resp.ResourceData = c
+}
+
+func (p *shieldooProvider) Resources(ctx context.Context) []func() resource.Resource {
+	return []func() resource.Resource{
+		newFirewallResource,
+		newServerResource,
+	}
+}
+
+func (p *shieldooProvider) DataSources(ctx context.Context) []func() datasource.DataSource {
+	return []func() datasource.DataSource{
+		newGroupDataSource,
+		newFirewallDataSource,
+		newServerDataSource,
+	}
+}
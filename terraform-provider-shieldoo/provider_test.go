@@ -0,0 +1,158 @@
+package main
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/shieldoo/shieldoo-cli/client"
+	"github.com/shieldoo/shieldoo-cli/mockapi"
+)
+
+// Acceptance tests run terraform against in-memory fake API, they are enabled by TF_ACC=1
+// (terraform binary is found in PATH or downloaded by terraform-plugin-testing).
+
+var testProviderFactories = map[string]func() (tfprotov6.ProviderServer, error){
+	"shieldoo": providerserver.NewProtocol6WithError(newProvider("test")()),
+}
+
+// newTestApi starts fake API with group admins and returns provider configuration for it
+func newTestApi(t *testing.T) string {
+	api := mockapi.New("test-key", "")
+	api.AddGroup(client.Group{Name: "admins", ObjectId: "admins-oid"})
+	srv := httptest.NewServer(api)
+	t.Cleanup(srv.Close)
+	return fmt.Sprintf(`
+provider "shieldoo" {
+  uri     = %q
+  api_key = "test-key"
+}
+`, srv.URL)
+}
+
+func TestAccFirewallResource(t *testing.T) {
+	provider := newTestApi(t)
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: provider + `
+data "shieldoo_group" "admins" {
+  name = "admins"
+}
+
+resource "shieldoo_firewall" "web" {
+  name = "web"
+  rules_in = [
+    { protocol = "tcp", port = "443", host = "group", group_ids = [data.shieldoo_group.admins.id] },
+  ]
+  rules_out = [
+    { protocol = "any", port = "any", host = "any" },
+  ]
+}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttrSet("shieldoo_firewall.web", "id"),
+					resource.TestCheckResourceAttr("shieldoo_firewall.web", "rules_in.#", "1"),
+					resource.TestCheckResourceAttrPair("shieldoo_firewall.web", "rules_in.0.group_ids.0", "data.shieldoo_group.admins", "id"),
+					resource.TestCheckResourceAttr("data.shieldoo_group.admins", "object_id", "admins-oid"),
+				),
+			},
+			{
+				ResourceName:      "shieldoo_firewall.web",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+			{
+				Config: provider + `
+resource "shieldoo_firewall" "web" {
+  name = "web-renamed"
+  rules_out = [
+    { protocol = "tcp", port = "8000-8080", host = "any" },
+  ]
+}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("shieldoo_firewall.web", "name", "web-renamed"),
+					resource.TestCheckNoResourceAttr("shieldoo_firewall.web", "rules_in"),
+					resource.TestCheckResourceAttr("shieldoo_firewall.web", "rules_out.0.port", "8000-8080"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccServerResource(t *testing.T) {
+	provider := newTestApi(t)
+	firewall := `
+data "shieldoo_group" "admins" {
+  name = "admins"
+}
+
+resource "shieldoo_firewall" "default" {
+  name = "default"
+  rules_out = [
+    { protocol = "any", port = "any", host = "any" },
+  ]
+}
+`
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: provider + firewall + `
+resource "shieldoo_server" "db" {
+  name        = "db"
+  firewall_id = shieldoo_firewall.default.id
+  group_ids   = [data.shieldoo_group.admins.id]
+  listeners = [
+    { listen_port = 5432, protocol = "tcp", forward_port = 5432, forward_host = "10.0.0.5" },
+  ]
+}
+
+data "shieldoo_server" "db" {
+  name = shieldoo_server.db.name
+}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttrSet("shieldoo_server.db", "ip_address"),
+					resource.TestCheckResourceAttrSet("shieldoo_server.db", "configuration"),
+					resource.TestCheckResourceAttr("shieldoo_server.db", "autoupdate", "false"),
+					resource.TestCheckResourceAttr("shieldoo_server.db", "listeners.0.forward_host", "10.0.0.5"),
+					resource.TestCheckResourceAttrPair("data.shieldoo_server.db", "id", "shieldoo_server.db", "id"),
+					resource.TestCheckResourceAttrPair("data.shieldoo_server.db", "ip_address", "shieldoo_server.db", "ip_address"),
+				),
+			},
+			{
+				ResourceName:      "shieldoo_server.db",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+			{
+				Config: provider + firewall + `
+resource "shieldoo_server" "db" {
+  name        = "db"
+  firewall_id = shieldoo_firewall.default.id
+  autoupdate  = true
+  description = "database"
+  os_update_policy = {
+    enabled                     = true
+    security_autoupdate_enabled = true
+    update_hour                 = 3
+  }
+}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("shieldoo_server.db", "autoupdate", "true"),
+					resource.TestCheckResourceAttr("shieldoo_server.db", "description", "database"),
+					resource.TestCheckResourceAttr("shieldoo_server.db", "os_update_policy.update_hour", "3"),
+					resource.TestCheckResourceAttr("shieldoo_server.db", "os_update_policy.all_autoupdate_enabled", "false"),
+					resource.TestCheckNoResourceAttr("shieldoo_server.db", "listeners"),
+				),
+			},
+		},
+	})
+}
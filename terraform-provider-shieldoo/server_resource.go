@@ -0,0 +1,201 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64default"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/shieldoo/shieldoo-cli/client"
+)
+
+type serverResource struct {
+	client *client.Client
+}
+
+func newServerResource() resource.Resource {
+	return &serverResource{}
+}
+
+func (r *serverResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_server"
+}
+
+func optionalBool(description string) schema.BoolAttribute {
+	return schema.BoolAttribute{
+		Description: description,
+		Optional:    true,
+		Computed:    true,
+		Default:     booldefault.StaticBool(false),
+	}
+}
+
+func (r *serverResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Server (machine running shieldoo client) which is accessible by users.",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Description:   "Server ID.",
+				Computed:      true,
+				PlanModifiers: []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
+			},
+			"name": schema.StringAttribute{
+				Description: "Server name.",
+				Required:    true,
+			},
+			"firewall_id": schema.StringAttribute{
+				Description: "ID of firewall assigned to server.",
+				Required:    true,
+			},
+			"group_ids": schema.ListAttribute{
+				Description: "IDs of groups whose members can access server.",
+				Optional:    true,
+				ElementType: types.StringType,
+			},
+			"listeners": schema.ListNestedAttribute{
+				Description: "Ports forwarded by server to other hosts.",
+				Optional:    true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"listen_port": schema.Int64Attribute{
+							Description: "Port listening on server.",
+							Required:    true,
+						},
+						"protocol": schema.StringAttribute{
+							Description: "Protocol: tcp or udp.",
+							Required:    true,
+						},
+						"forward_port": schema.Int64Attribute{
+							Description: "Port of target host.",
+							Required:    true,
+						},
+						"forward_host": schema.StringAttribute{
+							Description: "Target host.",
+							Required:    true,
+						},
+						"description": schema.StringAttribute{
+							Description: "Listener description.",
+							Optional:    true,
+						},
+					},
+				},
+			},
+			"autoupdate": optionalBool("Automatic update of shieldoo client."),
+			"ip_address": schema.StringAttribute{
+				Description:   "IP address of server in shieldoo network, assigned automatically if not set.",
+				Optional:      true,
+				Computed:      true,
+				PlanModifiers: []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
+			},
+			"description": schema.StringAttribute{
+				Description: "Server description.",
+				Optional:    true,
+			},
+			"os_update_policy": schema.SingleNestedAttribute{
+				Description: "Automatic update of server operating system.",
+				Optional:    true,
+				Attributes: map[string]schema.Attribute{
+					"enabled":                     optionalBool("Enable OS update policy."),
+					"security_autoupdate_enabled": optionalBool("Install security updates automatically."),
+					"all_autoupdate_enabled":      optionalBool("Install all updates automatically."),
+					"restart_after_update":        optionalBool("Restart server after update."),
+					"update_hour": schema.Int64Attribute{
+						Description: "Hour (0-23) when updates are installed.",
+						Optional:    true,
+						Computed:    true,
+						Default:     int64default.StaticInt64(0),
+					},
+				},
+			},
+			"configuration": schema.StringAttribute{
+				Description: "Configuration of shieldoo client for the server.",
+				Computed:    true,
+				Sensitive:   true,
+			},
+		},
+	}
+}
+
+func (r *serverResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	c, ok := req.ProviderData.(*client.Client)
+	if !ok {
+		resp.Diagnostics.AddError("Unexpected provider data", fmt.Sprintf("Expected *client.Client, got %T", req.ProviderData))
+		return
+	}
+	r.client = c
+}
+
+func (r *serverResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan serverModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	s, err := r.client.CreateServer(ctx, plan.toServer())
+	if err != nil {
+		resp.Diagnostics.AddError("Cannot create server", err.Error())
+		return
+	}
+	plan.fromServer(*s)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+func (r *serverResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state serverModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	s, err := r.client.GetServer(ctx, state.Id.ValueString())
+	if client.IsNotFound(err) {
+		// server was deleted outside of terraform
+		resp.State.RemoveResource(ctx)
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError("Cannot read server", err.Error())
+		return
+	}
+	state.fromServer(*s)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
+}
+
+func (r *serverResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan serverModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	s, err := r.client.UpdateServer(ctx, plan.toServer())
+	if err != nil {
+		resp.Diagnostics.AddError("Cannot update server", err.Error())
+		return
+	}
+	plan.fromServer(*s)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+func (r *serverResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var state serverModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	err := r.client.DeleteServer(ctx, state.Id.ValueString())
+	if err != nil && !client.IsNotFound(err) {
+		resp.Diagnostics.AddError("Cannot delete server", err.Error())
+	}
+}
+
+func (r *serverResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+)
+
+// tenantSnapshot contains all servers and firewalls, so usages are computed from one API call per kind
+type tenantSnapshot struct {
+	servers   []Server
+	firewalls []Firewall
+}
+
+func loadTenantSnapshot() (*tenantSnapshot, error) {
+	servers, err := listServers()
+	if err != nil {
+		return nil, err
+	}
+	firewalls, err := listFirewalls()
+	if err != nil {
+		return nil, err
+	}
+	return &tenantSnapshot{servers: servers, firewalls: firewalls}, nil
+}
+
+// firewallServers returns servers which use firewall
+func (t *tenantSnapshot) firewallServers(fwId string) []Server {
+	var ret []Server
+	for _, s := range t.servers {
+		if s.Firewall.Id == fwId {
+			ret = append(ret, s)
+		}
+	}
+	return ret
+}
+
+type serverRef struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+}
+
+func serverRefs(servers []Server) []serverRef {
+	ret := []serverRef{}
+	for _, s := range servers {
+		ret = append(ret, serverRef{Id: s.Id, Name: s.Name})
+	}
+	return ret
+}
+
+// firewallRuleRef identifies firewall rule, Index starts with 1,
+// Servers are servers which use the firewall
+type firewallRuleRef struct {
+	Firewall   string      `json:"firewall"`
+	FirewallId string      `json:"firewallId"`
+	Field      string      `json:"field"`
+	Index      int         `json:"index"`
+	Rule       string      `json:"rule"`
+	Servers    []serverRef `json:"servers"`
+}
+
+// firewallUsages lists servers which use firewall
+type firewallUsages struct {
+	Firewall serverRef   `json:"firewall"`
+	Servers  []serverRef `json:"servers"`
+}
+
+// groupUsages lists servers which are members of group and firewall rules which reference group
+type groupUsages struct {
+	Group         Group             `json:"group"`
+	Servers       []serverRef       `json:"servers"`
+	FirewallRules []firewallRuleRef `json:"firewallRules"`
+}
+
+func (t *tenantSnapshot) firewallUsages(fw Firewall) firewallUsages {
+	return firewallUsages{Firewall: serverRef{Id: fw.Id, Name: fw.Name}, Servers: serverRefs(t.firewallServers(fw.Id))}
+}
+
+func (t *tenantSnapshot) groupUsages(g Group) groupUsages {
+	ret := groupUsages{Group: g, Servers: []serverRef{}, FirewallRules: []firewallRuleRef{}}
+	ref := Group{Id: g.Id}
+	for _, s := range t.servers {
+		if containsGroup(s.Groups, ref) {
+			ret.Servers = append(ret.Servers, serverRef{Id: s.Id, Name: s.Name})
+		}
+	}
+	for _, fw := range t.firewalls {
+		for _, field := range []string{"rulesIn", "rulesOut"} {
+			rules := fw.RulesIn
+			if field == "rulesOut" {
+				rules = fw.RulesOut
+			}
+			for i, r := range rules {
+				if containsGroup(r.Groups, ref) {
+					ret.FirewallRules = append(ret.FirewallRules, firewallRuleRef{
+						Firewall:   fw.Name,
+						FirewallId: fw.Id,
+						Field:      field,
+						Index:      i + 1,
+						Rule:       formatFirewallRule(r),
+						Servers:    serverRefs(t.firewallServers(fw.Id)),
+					})
+				}
+			}
+		}
+	}
+	return ret
+}
+
+func firewallUsagesTable(usages []firewallUsages) ([]string, [][]string) {
+	header := []string{"FIREWALL", "SERVER", "SERVER ID"}
+	var rows [][]string
+	for _, u := range usages {
+		if len(u.Servers) == 0 {
+			rows = append(rows, []string{u.Firewall.Name, "", ""})
+		}
+		for _, s := range u.Servers {
+			rows = append(rows, []string{u.Firewall.Name, s.Name, s.Id})
+		}
+	}
+	return header, rows
+}
+
+// groupUsagesTable has row for each member server, each referencing rule and each server using firewall of the rule
+func groupUsagesTable(usages []groupUsages) ([]string, [][]string) {
+	header := []string{"GROUP", "KIND", "NAME", "ID", "USAGE"}
+	var rows [][]string
+	for _, u := range usages {
+		if len(u.Servers) == 0 && len(u.FirewallRules) == 0 {
+			rows = append(rows, []string{u.Group.Name, "", "", "", ""})
+		}
+		for _, s := range u.Servers {
+			rows = append(rows, []string{u.Group.Name, "server", s.Name, s.Id, "member"})
+		}
+		for _, r := range u.FirewallRules {
+			rows = append(rows, []string{u.Group.Name, "firewall", r.Firewall, r.FirewallId, fmt.Sprintf("%s #%d %s", r.Field, r.Index, r.Rule)})
+			for _, s := range r.Servers {
+				rows = append(rows, []string{u.Group.Name, "server", s.Name, s.Id, "firewall " + r.Firewall})
+			}
+		}
+	}
+	return header, rows
+}